@@ -0,0 +1,144 @@
+package bpe
+
+// BatchConfig is a configuration for padding and truncation of batches of encoded sentences.
+// It is created by NewBatchConfig.
+type BatchConfig struct {
+	maxLength      int
+	padToMaxLength bool
+	padLeft        bool
+	truncateLeft   bool
+}
+
+// BatchOption is a setting of BatchConfig
+type BatchOption func(*BatchConfig)
+
+// NewBatchConfig creates a configuration for padding and truncation of batches with the given
+// options applied. Without options the sentences are padded on the right to the length of
+// the longest one and never truncated.
+func NewBatchConfig(options ...BatchOption) BatchConfig {
+	var batchConfig BatchConfig
+	for _, option := range options {
+		option(&batchConfig)
+	}
+	return batchConfig
+}
+
+// WithMaxLength truncates the sentences which are longer than maxLength tokens
+func WithMaxLength(maxLength int) BatchOption {
+	return func(batchConfig *BatchConfig) {
+		batchConfig.maxLength = maxLength
+	}
+}
+
+// PadToMaxLength pads the sentences to the length set by WithMaxLength even if all of them
+// are shorter
+func PadToMaxLength() BatchOption {
+	return func(batchConfig *BatchConfig) {
+		batchConfig.padToMaxLength = true
+	}
+}
+
+// PadLeft puts the padding before the tokens instead of after them
+func PadLeft() BatchOption {
+	return func(batchConfig *BatchConfig) {
+		batchConfig.padLeft = true
+	}
+}
+
+// TruncateLeft drops the first tokens of the long sentences instead of the last ones
+func TruncateLeft() BatchOption {
+	return func(batchConfig *BatchConfig) {
+		batchConfig.truncateLeft = true
+	}
+}
+
+// Batch is a batch of encoded sentences padded to the same length
+type Batch struct {
+	// IDs are the token ids, one row per sentence
+	IDs [][]TokenID
+	// AttentionMask is 1 for the tokens of the sentences and 0 for the padding
+	AttentionMask [][]uint8
+}
+
+// Shape returns the number of sentences and the number of tokens in each of them
+func (b Batch) Shape() (int, int) {
+	if len(b.IDs) == 0 {
+		return 0, 0
+	}
+	return len(b.IDs), len(b.IDs[0])
+}
+
+// Flat returns the token ids and the attention mask as dense row-major arrays, which is
+// the layout most tensor libraries expect
+func (b Batch) Flat() ([]int32, []int32) {
+	rows, cols := b.Shape()
+	ids := make([]int32, 0, rows*cols)
+	attentionMask := make([]int32, 0, rows*cols)
+	for i, row := range b.IDs {
+		for j, id := range row {
+			ids = append(ids, int32(id))
+			attentionMask = append(attentionMask, int32(b.AttentionMask[i][j]))
+		}
+	}
+	return ids, attentionMask
+}
+
+// PadBatch truncates and pads the encoded sentences with PAD token according to batchConfig.
+// The model must have PAD token.
+func (m Model) PadBatch(encodedSentences []EncodedString, batchConfig BatchConfig) (Batch,
+	error) {
+	if m.specialTokens.pad == -1 {
+		logger.Errorf("Cannot use pad - model was trained without it")
+		return Batch{}, &SpecialTokenMissingError{padToken}
+	}
+	length := 0
+	for _, encodedSentence := range encodedSentences {
+		if len(encodedSentence) > length {
+			length = len(encodedSentence)
+		}
+	}
+	if batchConfig.maxLength > 0 &&
+		(length > batchConfig.maxLength || batchConfig.padToMaxLength) {
+		length = batchConfig.maxLength
+	}
+	batch := Batch{
+		make([][]TokenID, len(encodedSentences)),
+		make([][]uint8, len(encodedSentences)),
+	}
+	for i, encodedSentence := range encodedSentences {
+		if len(encodedSentence) > length {
+			if batchConfig.truncateLeft {
+				encodedSentence = encodedSentence[len(encodedSentence)-length:]
+			} else {
+				encodedSentence = encodedSentence[:length]
+			}
+		}
+		ids := make([]TokenID, length)
+		attentionMask := make([]uint8, length)
+		padding := length - len(encodedSentence)
+		start := 0
+		if batchConfig.padLeft {
+			start = padding
+		}
+		for j := range ids {
+			ids[j] = TokenID(m.specialTokens.pad)
+		}
+		copy(ids[start:], encodedSentence)
+		for j := start; j < start+len(encodedSentence); j++ {
+			attentionMask[j] = 1
+		}
+		batch.IDs[i] = ids
+		batch.AttentionMask[i] = attentionMask
+	}
+	return batch, nil
+}
+
+// EncodeBatch encodes the sentences with EncodeSentences and pads the result with PadBatch
+func (m Model) EncodeBatch(sentences []string, encodingConfig EncodingConfig,
+	batchConfig BatchConfig) (Batch, error) {
+	encodedSentences, err := m.EncodeSentences(sentences, encodingConfig)
+	if err != nil {
+		return Batch{}, err
+	}
+	return m.PadBatch(encodedSentences, batchConfig)
+}
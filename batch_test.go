@@ -0,0 +1,76 @@
+package bpe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewBatchConfig(t *testing.T) {
+	req := require.New(t)
+	req.Equal(BatchConfig{}, NewBatchConfig())
+	req.Equal(BatchConfig{10, true, true, true}, NewBatchConfig(WithMaxLength(10),
+		PadToMaxLength(), PadLeft(), TruncateLeft()))
+}
+
+func TestModel_PadBatch(t *testing.T) {
+	req := require.New(t)
+	encoded := []EncodedString{{9, 7, 6}, {12}, {}, {9, 7, 6, 5, 8}}
+	batch, err := BPE.PadBatch(encoded, NewBatchConfig())
+	req.NoError(err)
+	req.Equal([][]TokenID{{9, 7, 6, 0, 0}, {12, 0, 0, 0, 0}, {0, 0, 0, 0, 0}, {9, 7, 6, 5, 8}},
+		batch.IDs)
+	req.Equal([][]uint8{{1, 1, 1, 0, 0}, {1, 0, 0, 0, 0}, {0, 0, 0, 0, 0}, {1, 1, 1, 1, 1}},
+		batch.AttentionMask)
+	rows, cols := batch.Shape()
+	req.Equal(4, rows)
+	req.Equal(5, cols)
+
+	batch, err = BPE.PadBatch(encoded, NewBatchConfig(WithMaxLength(4), PadLeft()))
+	req.NoError(err)
+	req.Equal([][]TokenID{{0, 9, 7, 6}, {0, 0, 0, 12}, {0, 0, 0, 0}, {9, 7, 6, 5}}, batch.IDs)
+	req.Equal([][]uint8{{0, 1, 1, 1}, {0, 0, 0, 1}, {0, 0, 0, 0}, {1, 1, 1, 1}},
+		batch.AttentionMask)
+
+	batch, err = BPE.PadBatch(encoded, NewBatchConfig(WithMaxLength(2), TruncateLeft()))
+	req.NoError(err)
+	req.Equal([][]TokenID{{7, 6}, {12, 0}, {0, 0}, {5, 8}}, batch.IDs)
+
+	batch, err = BPE.PadBatch(encoded[:2], NewBatchConfig(WithMaxLength(4), PadToMaxLength()))
+	req.NoError(err)
+	req.Equal([][]TokenID{{9, 7, 6, 0}, {12, 0, 0, 0}}, batch.IDs)
+
+	batch, err = BPE.PadBatch(nil, NewBatchConfig())
+	req.NoError(err)
+	rows, cols = batch.Shape()
+	req.Equal(0, rows)
+	req.Equal(0, cols)
+
+	model := BPE
+	model.specialTokens.pad = -1
+	_, err = model.PadBatch(encoded, NewBatchConfig())
+	req.Error(err)
+}
+
+func TestBatch_Flat(t *testing.T) {
+	req := require.New(t)
+	batch, err := BPE.PadBatch([]EncodedString{{9, 7, 6}, {12}}, NewBatchConfig())
+	req.NoError(err)
+	ids, attentionMask := batch.Flat()
+	req.Equal([]int32{9, 7, 6, 12, 0, 0}, ids)
+	req.Equal([]int32{1, 1, 1, 1, 0, 0}, attentionMask)
+}
+
+func TestModel_EncodeBatch(t *testing.T) {
+	req := require.New(t)
+	batch, err := BPE.EncodeBatch([]string{"abc", "b"}, NewEncodingConfig(WithBOS()),
+		NewBatchConfig())
+	req.NoError(err)
+	req.Equal([][]TokenID{{2, 9, 7, 6}, {2, 12, 0, 0}}, batch.IDs)
+
+	model := BPE
+	model.specialTokens.bos = -1
+	_, err = model.EncodeBatch([]string{"abc", "b"}, NewEncodingConfig(WithBOS()),
+		NewBatchConfig())
+	req.Error(err)
+}
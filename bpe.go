@@ -1,21 +1,19 @@
 package bpe
 
 import (
-	"bufio"
-	"container/heap"
 	"encoding/binary"
-	"errors"
 	"io"
-	"strconv"
-	"strings"
-
-	"github.com/sirupsen/logrus"
+	"math/rand"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 // TokenID is a numerical identifier of the subword token
 type TokenID uint32
 
-// TokenIDPair is a concatenation of two TokenIDs that is used as the key type in rule2id map.
+// TokenIDPair is a concatenation of two TokenIDs that is used as the key type in rule2id table.
 type TokenIDPair uint64
 
 // EncodedString is a sequence of subword token identifiers
@@ -28,11 +26,175 @@ const (
 	eosToken = "<EOS>"
 )
 
-// EncodingConfig is a configuration for encoding of strings
+// EncodingConfig is a configuration for encoding of strings. It is created by NewEncodingConfig.
 type EncodingConfig struct {
-	bos     bool
-	eos     bool
-	reverse bool
+	bos          bool
+	eos          bool
+	reverse      bool
+	dropout      float64
+	rand         *rand.Rand
+	cache        *WordCache
+	normalizer   Normalizer
+	preTokenizer PreTokenizer
+}
+
+// EncodingOption is a setting of EncodingConfig
+type EncodingOption func(*EncodingConfig)
+
+// NewEncodingConfig creates a configuration for encoding of strings with the given options
+// applied. Without options the sentences are encoded as is.
+func NewEncodingConfig(options ...EncodingOption) EncodingConfig {
+	var encodingConfig EncodingConfig
+	for _, option := range options {
+		option(&encodingConfig)
+	}
+	return encodingConfig
+}
+
+// WithBOS adds BOS token to the beginning of every encoded sentence
+func WithBOS() EncodingOption {
+	return func(encodingConfig *EncodingConfig) {
+		encodingConfig.bos = true
+	}
+}
+
+// WithEOS adds EOS token to the end of every encoded sentence
+func WithEOS() EncodingOption {
+	return func(encodingConfig *EncodingConfig) {
+		encodingConfig.eos = true
+	}
+}
+
+// Reversed reverses the output sequences, BOS and EOS tokens included
+func Reversed() EncodingOption {
+	return func(encodingConfig *EncodingConfig) {
+		encodingConfig.reverse = true
+	}
+}
+
+// DecodingConfig is a configuration for decoding of token sequences. It is created by
+// NewDecodingConfig.
+type DecodingConfig struct {
+	skipPAD   bool
+	skipUNK   bool
+	skipBOS   bool
+	skipEOS   bool
+	stopAtEOS bool
+	reverse   bool
+	unk       string
+}
+
+// DecodingOption is a setting of DecodingConfig
+type DecodingOption func(*DecodingConfig)
+
+// NewDecodingConfig creates a configuration for decoding of token sequences with the given
+// options applied. Without options special tokens are rendered as <PAD>, <UNK>, <BOS> and <EOS>.
+func NewDecodingConfig(options ...DecodingOption) DecodingConfig {
+	var decodingConfig DecodingConfig
+	for _, option := range options {
+		option(&decodingConfig)
+	}
+	return decodingConfig
+}
+
+// SkipSpecialTokens removes PAD, UNK, BOS and EOS tokens from the decoded sentences
+func SkipSpecialTokens() DecodingOption {
+	return func(decodingConfig *DecodingConfig) {
+		decodingConfig.skipPAD = true
+		decodingConfig.skipUNK = true
+		decodingConfig.skipBOS = true
+		decodingConfig.skipEOS = true
+	}
+}
+
+// SkipPAD removes PAD tokens from the decoded sentences
+func SkipPAD() DecodingOption {
+	return func(decodingConfig *DecodingConfig) {
+		decodingConfig.skipPAD = true
+	}
+}
+
+// SkipUNK removes UNK tokens from the decoded sentences
+func SkipUNK() DecodingOption {
+	return func(decodingConfig *DecodingConfig) {
+		decodingConfig.skipUNK = true
+	}
+}
+
+// SkipBOS removes BOS tokens from the decoded sentences
+func SkipBOS() DecodingOption {
+	return func(decodingConfig *DecodingConfig) {
+		decodingConfig.skipBOS = true
+	}
+}
+
+// SkipEOS removes EOS tokens from the decoded sentences
+func SkipEOS() DecodingOption {
+	return func(decodingConfig *DecodingConfig) {
+		decodingConfig.skipEOS = true
+	}
+}
+
+// StopAtEOS drops everything after the first EOS token of every sequence
+func StopAtEOS() DecodingOption {
+	return func(decodingConfig *DecodingConfig) {
+		decodingConfig.stopAtEOS = true
+	}
+}
+
+// ReversedInput reverses the sequences before decoding, it undoes Reversed encoding option
+func ReversedInput() DecodingOption {
+	return func(decodingConfig *DecodingConfig) {
+		decodingConfig.reverse = true
+	}
+}
+
+// WithUNKString renders UNK tokens as the given string instead of <UNK>. An empty string
+// keeps <UNK>, use SkipUNK to remove the tokens.
+func WithUNKString(unk string) DecodingOption {
+	return func(decodingConfig *DecodingConfig) {
+		decodingConfig.unk = unk
+	}
+}
+
+// WithDropout enables BPE-dropout: every merge is skipped with the given probability,
+// so the same word may be split into different subwords. The randomness is drawn from
+// the source; nil source means a source seeded with the current time. The source is guarded
+// by a mutex, so the configuration can be shared between goroutines. The option may be applied
+// to several configurations: they share the given source, while nil source gives every one
+// of them its own.
+func WithDropout(probability float64, source rand.Source) EncodingOption {
+	// The same mutex must guard the source in all the configurations
+	var shared *lockedSource
+	if source != nil {
+		shared = &lockedSource{source: source}
+	}
+	return func(encodingConfig *EncodingConfig) {
+		locked := shared
+		if locked == nil {
+			locked = &lockedSource{source: rand.NewSource(time.Now().UnixNano())}
+		}
+		encodingConfig.dropout = probability
+		encodingConfig.rand = rand.New(locked)
+	}
+}
+
+// lockedSource is rand.Source which is safe for concurrent use.
+type lockedSource struct {
+	lock   sync.Mutex
+	source rand.Source
+}
+
+func (ls *lockedSource) Int63() int64 {
+	ls.lock.Lock()
+	defer ls.lock.Unlock()
+	return ls.source.Int63()
+}
+
+func (ls *lockedSource) Seed(seed int64) {
+	ls.lock.Lock()
+	defer ls.lock.Unlock()
+	ls.source.Seed(seed)
 }
 
 type rule struct {
@@ -52,11 +214,12 @@ type specialTokens struct {
 // of most frequent subword tokens
 type Model struct {
 	char2id       map[rune]TokenID
-	id2char       map[TokenID]rune
 	rules         []rule
-	rule2id       map[TokenIDPair]int
-	recipe        map[TokenID]EncodedString
+	rule2id       pairTable
+	tokens        []tokenEntry // indexed by TokenID
+	mergedText    []byte       // texts of the merged tokens one after another
 	revRecipe     map[string]TokenID
+	textIndex     []TokenID // ids sorted by token text, replaces revRecipe in mapped models
 	specialTokens specialTokens
 	spaceID       TokenID
 }
@@ -64,11 +227,12 @@ type Model struct {
 func newModel(nRules int) *Model {
 	return &Model{
 		make(map[rune]TokenID),
-		make(map[TokenID]rune),
 		make([]rule, nRules),
-		make(map[TokenIDPair]int),
-		make(map[TokenID]EncodedString),
+		newPairTable(nRules),
+		nil,
+		nil,
 		make(map[string]TokenID),
+		nil,
 		specialTokens{-1, -1, -1, -1},
 		0,
 	}
@@ -86,8 +250,8 @@ func DecodeToken(token EncodedString, id2char map[TokenID]rune) (string, error)
 		if char, ok := id2char[id]; ok {
 			word = word + string(char)
 		} else {
-			logrus.Errorf("Decode failure: %d token id has no corresponding char", id)
-			return "", errors.New("key not found in id2char")
+			logger.Errorf("Decode failure: %d token id has no corresponding char", id)
+			return "", &UnknownTokenIDError{id}
 		}
 	}
 	return word, nil
@@ -105,8 +269,8 @@ func (s specialTokens) toBinary() []byte {
 func binaryToSpecialTokens(bytesArray []byte) (specialTokens, error) {
 	var s specialTokens
 	if len(bytesArray) < 16 {
-		logrus.Error("Bytes array length is too small")
-		return s, errors.New("bytes array is too small")
+		logger.Errorf("Bytes array length is too small")
+		return s, &TruncatedModelError{int64(len(bytesArray))}
 	}
 	s.unk = int32(binary.BigEndian.Uint32(bytesArray))
 	s.pad = int32(binary.BigEndian.Uint32(bytesArray[4:]))
@@ -126,8 +290,8 @@ func (r rule) toBinary() []byte {
 func binaryToRule(bytesArray []byte) (rule, error) {
 	var r rule
 	if len(bytesArray) < 12 {
-		logrus.Error("Bytes array length is too small")
-		return r, errors.New("bytes array is too small")
+		logger.Errorf("Bytes array length is too small")
+		return r, &TruncatedModelError{int64(len(bytesArray))}
 	}
 	r.left = TokenID(binary.BigEndian.Uint32(bytesArray))
 	r.right = TokenID(binary.BigEndian.Uint32(bytesArray[4:]))
@@ -135,146 +299,329 @@ func binaryToRule(bytesArray []byte) (rule, error) {
 	return r, nil
 }
 
-// ReadModel loads the BPE model from the binary dump
+// ReadModel loads the BPE model from the binary dump. If the dump ends prematurely,
+// the error is *TruncatedModelError. Only the ids of the tokens and the order of the rules are
+// checked; ReadModelStrict checks everything.
 func ReadModel(reader io.Reader) (*Model, error) {
-	buf := make([]byte, 4)
-	var nChars, nRules int
-	if _, err := io.ReadFull(reader, buf); err != nil {
-		logrus.Error("Broken input: ", err)
-		return &Model{}, err
-	}
-	nChars = int(binary.BigEndian.Uint32(buf))
-	if _, err := io.ReadFull(reader, buf); err != nil {
-		logrus.Error("Broken input: ", err)
+	dump, err := readModelDump(reader)
+	if err != nil {
 		return &Model{}, err
 	}
-	nRules = int(binary.BigEndian.Uint32(buf))
+	return dump.build()
+}
 
-	model := newModel(nRules)
-	minCharID := TokenID(0)
+// modelDump is the contents of the binary dump of the model as they are, without any checks
+type modelDump struct {
+	chars    []dumpChar
+	rules    []rule
+	specials specialTokens
+}
+
+type dumpChar struct {
+	char rune
+	id   TokenID
+}
+
+// readModelDump reads the binary dump up to the special tokens. Whatever follows them is
+// not read.
+func readModelDump(reader io.Reader) (modelDump, error) {
+	var dump modelDump
+	var offset int64
+	readFull := func(buf []byte) error {
+		n, err := io.ReadFull(reader, buf)
+		offset += int64(n)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			err = &TruncatedModelError{offset}
+		}
+		if err != nil {
+			logger.Errorf("Broken input: %v", err)
+		}
+		return err
+	}
+	buf := make([]byte, 16)
+	if err := readFull(buf[:8]); err != nil {
+		return dump, err
+	}
+	nChars := int(binary.BigEndian.Uint32(buf))
+	nRules := int(binary.BigEndian.Uint32(buf[4:]))
+	// The counts are not trusted to preallocate the memory, a corrupted dump would be truncated
 	for i := 0; i < nChars; i++ {
-		var char rune
-		var charID TokenID
-		if _, err := io.ReadFull(reader, buf); err != nil {
-			logrus.Error("Broken input: ", err)
-			return &Model{}, err
-		}
-		char = rune(binary.BigEndian.Uint32(buf))
-		if _, err := io.ReadFull(reader, buf); err != nil {
-			logrus.Error("Broken input: ", err)
-			return &Model{}, err
-		}
-		charID = TokenID(binary.BigEndian.Uint32(buf))
-		model.char2id[char] = charID
-		model.id2char[charID] = char
-		model.recipe[charID] = EncodedString{charID}
-		model.revRecipe[string(char)] = charID
-		if charID < minCharID || minCharID == 0 {
-			minCharID = charID
-			model.spaceID = charID
-		}
-	}
-	ruleBuf := make([]byte, 12)
+		if err := readFull(buf[:8]); err != nil {
+			return dump, err
+		}
+		dump.chars = append(dump.chars, dumpChar{rune(binary.BigEndian.Uint32(buf)),
+			TokenID(binary.BigEndian.Uint32(buf[4:]))})
+	}
 	for i := 0; i < nRules; i++ {
-		if _, err := io.ReadFull(reader, ruleBuf); err != nil {
-			logrus.Error("Broken input: ", err)
-			return &Model{}, err
+		if err := readFull(buf[:12]); err != nil {
+			return dump, err
 		}
-		rule, err := binaryToRule(ruleBuf)
+		rule, err := binaryToRule(buf[:12])
 		if err != nil {
+			return dump, err
+		}
+		dump.rules = append(dump.rules, rule)
+	}
+	if err := readFull(buf); err != nil {
+		return dump, err
+	}
+	specials, err := binaryToSpecialTokens(buf)
+	dump.specials = specials
+	return dump, err
+}
+
+// build creates the model from the dump. The operands of every rule must have been defined
+// before it.
+func (dump modelDump) build() (*Model, error) {
+	// The ids of the tokens are dense, so the ids outside of the vocabulary are impossible.
+	// The check keeps corrupted dumps from allocating huge token tables.
+	maxID := TokenID(len(dump.chars) + len(dump.rules) + 4)
+	checkID := func(id TokenID) error {
+		if id >= maxID {
+			logger.Errorf("%d: token id is out of the vocabulary", id)
+			return &UnknownTokenIDError{id}
+		}
+		return nil
+	}
+
+	model := newModel(len(dump.rules))
+	minCharID := TokenID(0)
+	for _, char := range dump.chars {
+		if err := checkID(char.id); err != nil {
 			return model, err
 		}
-		if _, ok := model.recipe[rule.left]; !ok {
-			logrus.Errorf("%d: token id not described before", rule.left)
-			return model, errors.New("token id is impossible")
+		model.addChar(char.char, char.id)
+		if char.id < minCharID || minCharID == 0 {
+			minCharID = char.id
+			model.spaceID = char.id
+		}
+	}
+	if spaceID, ok := model.char2id[spaceToken]; ok {
+		// The space token is ranked among the chars by frequency, so it is not always the first
+		model.spaceID = spaceID
+	}
+	for i, rule := range dump.rules {
+		if err := checkID(rule.result); err != nil {
+			return model, err
 		}
-		if _, ok := model.recipe[rule.right]; !ok {
-			logrus.Errorf("%d: token id not described before", rule.right)
-			return model, errors.New("token id is impossible")
+		if err := model.addRule(i, rule); err != nil {
+			return model, err
 		}
-		model.rules[i] = rule
-		model.rule2id[newTokenIDPair(rule.left, rule.right)] = i
-		model.recipe[rule.result] = append(model.recipe[rule.left], model.recipe[rule.right]...)
-		resultString, err := DecodeToken(model.recipe[rule.result], model.id2char)
+	}
+	model.setSpecialTokens(dump.specials)
+	return model, nil
+}
+
+// WriteTo writes the BPE model to the writer in the binary format which is read by ReadModel.
+// It returns the number of written bytes.
+func (m Model) WriteTo(writer io.Writer) (int64, error) {
+	var written int64
+	write := func(bytesArray []byte) error {
+		n, err := writer.Write(bytesArray)
+		written += int64(n)
 		if err != nil {
-			logrus.Error("Unexpected token id inside the rules: ", err)
-			return model, err
+			logger.Errorf("Failed to write the model: %v", err)
 		}
-		model.revRecipe[resultString] = rule.result
+		return err
 	}
-	specialTokensBuf := make([]byte, 16)
-	if _, err := io.ReadFull(reader, specialTokensBuf); err != nil {
-		logrus.Error("Broken input: ", err)
-		return &Model{}, err
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint32(buf, uint32(len(m.char2id)))
+	binary.BigEndian.PutUint32(buf[4:], uint32(len(m.rules)))
+	if err := write(buf); err != nil {
+		return written, err
 	}
-	specials, err := binaryToSpecialTokens(specialTokensBuf)
-	if err != nil {
-		return model, err
+	for charID, token := range m.tokens {
+		if token.char == -1 {
+			continue
+		}
+		binary.BigEndian.PutUint32(buf, uint32(token.char))
+		binary.BigEndian.PutUint32(buf[4:], uint32(charID))
+		if err := write(buf); err != nil {
+			return written, err
+		}
+	}
+	for _, rule := range m.rules {
+		if err := write(rule.toBinary()); err != nil {
+			return written, err
+		}
+	}
+	err := write(m.specialTokens.toBinary())
+	return written, err
+}
+
+// hasToken checks whether the id belongs to a char or a merged token of the model.
+func (m Model) hasToken(id TokenID) bool {
+	return int64(id) < int64(len(m.tokens)) && m.tokens[id].exists()
+}
+
+// setToken stores the description of the token, growing the table of tokens if needed.
+func (m *Model) setToken(id TokenID, token tokenEntry) {
+	for int64(len(m.tokens)) <= int64(id) {
+		m.tokens = append(m.tokens, absentToken)
+	}
+	m.tokens[id] = token
+}
+
+// appendToken appends the text of the existing token to dst.
+func (m Model) appendToken(dst []byte, id TokenID) []byte {
+	token := m.tokens[id]
+	if token.char != -1 {
+		var buf [utf8.UTFMax]byte
+		return append(dst, buf[:utf8.EncodeRune(buf[:], token.char)]...)
+	}
+	return append(dst, m.mergedText[token.start:token.end]...)
+}
+
+// addChar registers a single character token in all the lookup tables of the model.
+func (m *Model) addChar(char rune, charID TokenID) {
+	m.char2id[char] = charID
+	m.setToken(charID, tokenEntry{char, 0, 0})
+	m.revRecipe[string(char)] = charID
+}
+
+// addRule registers the i-th merge rule in the model. Both operands of the rule must have been
+// registered before.
+func (m *Model) addRule(i int, r rule) error {
+	if !m.hasToken(r.left) {
+		logger.Errorf("%d: token id not described before", r.left)
+		return &UnknownTokenIDError{r.left}
+	}
+	if !m.hasToken(r.right) {
+		logger.Errorf("%d: token id not described before", r.right)
+		return &UnknownTokenIDError{r.right}
+	}
+	m.rules[i] = r
+	m.rule2id.put(newTokenIDPair(r.left, r.right), i)
+	start := len(m.mergedText)
+	m.mergedText = m.appendToken(m.mergedText, r.left)
+	m.mergedText = m.appendToken(m.mergedText, r.right)
+	m.setToken(r.result, tokenEntry{-1, uint32(start), uint32(len(m.mergedText))})
+	m.revRecipe[string(m.mergedText[start:])] = r.result
+	return nil
+}
+
+// setSpecialTokens stores the ids of the special tokens and makes them reachable by name.
+func (m *Model) setSpecialTokens(specials specialTokens) {
+	m.specialTokens = specials
+	m.revRecipe[bosToken] = TokenID(specials.bos)
+	m.revRecipe[eosToken] = TokenID(specials.eos)
+	m.revRecipe[unkToken] = TokenID(specials.unk)
+	m.revRecipe[padToken] = TokenID(specials.pad)
+}
+
+// specialToken returns the name of the special token with the given id. The second value is
+// false if the id does not belong to an enabled special token.
+func (m Model) specialToken(id TokenID) (string, bool) {
+	if m.hasToken(id) {
+		return "", false
 	}
-	model.specialTokens = specials
-	model.revRecipe[bosToken] = TokenID(specials.bos)
-	model.revRecipe[eosToken] = TokenID(specials.eos)
-	model.revRecipe[unkToken] = TokenID(specials.unk)
-	model.revRecipe[padToken] = TokenID(specials.pad)
-	return model, err
+	switch {
+	case m.specialTokens.unk != -1 && id == TokenID(m.specialTokens.unk):
+		return unkToken, true
+	case m.specialTokens.pad != -1 && id == TokenID(m.specialTokens.pad):
+		return padToken, true
+	case m.specialTokens.bos != -1 && id == TokenID(m.specialTokens.bos):
+		return bosToken, true
+	case m.specialTokens.eos != -1 && id == TokenID(m.specialTokens.eos):
+		return eosToken, true
+	}
+	return "", false
 }
 
 // IDToToken returns string token corresponding to the given token id.
 // If replaceSpace is true, special space token that is used for marking starts of words
 // will be replaced with space.
 func (m Model) IDToToken(id TokenID, replaceSpace bool) (string, error) {
-	if _, ok := m.recipe[id]; !ok {
-		switch id {
-		case TokenID(m.specialTokens.unk):
-			return unkToken, nil
-		case TokenID(m.specialTokens.pad):
-			return padToken, nil
-		case TokenID(m.specialTokens.bos):
-			return bosToken, nil
-		case TokenID(m.specialTokens.eos):
-			return eosToken, nil
-		default:
-			logrus.Errorf("%d: token id is impossible", id)
-			return "", errors.New("token id is impossible")
-		}
-	}
-	encodedToken, _ := m.recipe[id]
-	if encodedToken[0] == m.spaceID && replaceSpace {
-		token, err := DecodeToken(encodedToken[1:], m.id2char)
-		if err != nil {
-			return "", err
-		}
-		return " " + token, nil
+	if special, ok := m.specialToken(id); ok {
+		return special, nil
 	}
-	return DecodeToken(encodedToken, m.id2char)
+	if !m.hasToken(id) {
+		logger.Errorf("%d: token id is impossible", id)
+		return "", &UnknownTokenIDError{id}
+	}
+	return string(m.appendTokenText(nil, id, replaceSpace)), nil
+}
+
+// appendTokenText appends the text of the existing token to dst. If replaceSpace is true,
+// special space token that is used for marking starts of words is replaced with space.
+func (m Model) appendTokenText(dst []byte, id TokenID, replaceSpace bool) []byte {
+	start := len(dst)
+	dst = m.appendToken(dst, id)
+	if !replaceSpace || !m.hasToken(m.spaceID) {
+		return dst
+	}
+	if char, size := utf8.DecodeRune(dst[start:]); char == m.tokens[m.spaceID].char {
+		dst[start] = ' '
+		dst = append(dst[:start+1], dst[start+size:]...)
+	}
+	return dst
 }
 
 // DecodeSentence decodes a sequence of token ids in a text sentence - string of words
-// with spaces in between
-func (m Model) DecodeSentence(encodedSentence EncodedString) (string, error) {
-	sentence := ""
-	for _, tokenID := range encodedSentence {
-		token, err := m.IDToToken(tokenID, true)
-		if err != nil {
-			return sentence, err
+// with spaces in between. Any sequence of known ids can be decoded, including the empty one.
+// The first word has no space in front of it, even if it follows special tokens.
+// Through decodingConfig one can state which special tokens to drop, whether to stop at EOS,
+// whether the sequence was reversed and how to render UNK tokens.
+func (m Model) DecodeSentence(encodedSentence EncodedString, decodingConfig DecodingConfig,
+) (string, error) {
+	if decodingConfig.reverse {
+		reversed := make(EncodedString, len(encodedSentence))
+		for i, tokenID := range encodedSentence {
+			reversed[len(encodedSentence)-i-1] = tokenID
 		}
-		sentence += token
+		encodedSentence = reversed
 	}
-	if string(sentence[0]) == " " {
-		sentence = sentence[1:]
-	}
-	if sentence[:len(bosToken)+1] == bosToken+" " {
-		sentence = bosToken + sentence[len(bosToken)+1:]
+	var sentence []byte
+	firstWord := true
+	for _, tokenID := range encodedSentence {
+		if special, ok := m.specialToken(tokenID); ok {
+			skip, rendered := false, special
+			switch special {
+			case padToken:
+				skip = decodingConfig.skipPAD
+			case unkToken:
+				skip = decodingConfig.skipUNK
+				if decodingConfig.unk != "" {
+					rendered = decodingConfig.unk
+				}
+				// UNK stands for the text which was there, unlike the other special tokens
+				firstWord = firstWord && skip
+			case bosToken:
+				skip = decodingConfig.skipBOS
+			case eosToken:
+				skip = decodingConfig.skipEOS
+			}
+			if !skip {
+				sentence = append(sentence, rendered...)
+			}
+			if special == eosToken && decodingConfig.stopAtEOS {
+				break
+			}
+			continue
+		}
+		if !m.hasToken(tokenID) {
+			logger.Errorf("%d: token id is impossible", tokenID)
+			return string(sentence), &UnknownTokenIDError{tokenID}
+		}
+		start := len(sentence)
+		sentence = m.appendTokenText(sentence, tokenID, true)
+		if firstWord {
+			if sentence[start] == ' ' {
+				sentence = append(sentence[:start], sentence[start+1:]...)
+			}
+			firstWord = false
+		}
 	}
-	return sentence, nil
+	return string(sentence), nil
 }
 
 // DecodeSentences decodes a sequence of encoded sentences - sequences of token ids -
 // into a sequence of corresponding text sentences
-func (m Model) DecodeSentences(encodedSentences []EncodedString) ([]string, error) {
+func (m Model) DecodeSentences(encodedSentences []EncodedString, decodingConfig DecodingConfig,
+) ([]string, error) {
 	sentences := make([]string, len(encodedSentences))
 	for i, encodedSentence := range encodedSentences {
-		sentence, err := m.DecodeSentence(encodedSentence)
+		sentence, err := m.DecodeSentence(encodedSentence, decodingConfig)
 		if err != nil {
 			return sentences, err
 		}
@@ -284,173 +631,256 @@ func (m Model) DecodeSentences(encodedSentences []EncodedString) ([]string, erro
 }
 
 // DecodeFromStream decodes a sequence of encoded sentences written in an input stream
-// using Model.DecodeSentences
-func (m Model) DecodeFromStream(reader io.Reader) ([]string, error) {
-	scanner := bufio.NewScanner(reader)
+// using Model.DecodeSentences. The lines of the stream are not limited in length; errors
+// which are specific to a line are reported as *LineError.
+func (m Model) DecodeFromStream(reader io.Reader, decodingConfig DecodingConfig) ([]string,
+	error) {
+	decoder := m.NewStreamDecoder(reader, decodingConfig)
 	var sentences []string
-	for scanner.Scan() {
-		numbers := strings.Fields(scanner.Text())
-		var encodedSentence = make([]TokenID, len(numbers))
-		for i, number := range numbers {
-			id, err := strconv.Atoi(number)
-			if err != nil {
-				return nil, err
-			}
-			encodedSentence[i] = TokenID(id)
-		}
-		sentence, err := m.DecodeSentence(encodedSentence)
-		if err != nil {
-			return sentences, err
-		}
-		sentences = append(sentences, sentence)
-	}
-	if err := scanner.Err(); err != nil {
-		return sentences, err
+	for decoder.Next() {
+		sentences = append(sentences, decoder.Decoded())
 	}
-	return sentences, nil
+	return sentences, decoder.Err()
 }
 
 type encodingToken struct {
-	id   TokenID
-	prev int
-	next int
+	id    TokenID
+	prev  int
+	next  int
+	start int
+	end   int
 }
 
-type mergeEvent struct {
-	priority int
-	pos      int
+// Offset is the span of bytes in the encoded sentence which corresponds to a token.
+// Start is inclusive and End is exclusive.
+type Offset struct {
+	Start int
+	End   int
 }
 
-type mergeQueue []*mergeEvent
-
-func (mq mergeQueue) Len() int { return len(mq) }
-
-func (mq mergeQueue) Less(i, j int) bool {
-	return mq[i].priority < mq[j].priority ||
-		mq[i].priority == mq[j].priority && mq[i].pos < mq[j].pos
+// nextWord finds the first word of the sentence which starts at pos or later. Words are split
+// on white space the same way strings.Fields does. start is -1 if there are no more words.
+func nextWord(sentence string, pos int) (start, end int) {
+	start = -1
+	for pos < len(sentence) {
+		char, size := rune(sentence[pos]), 1
+		if char >= utf8.RuneSelf {
+			char, size = utf8.DecodeRuneInString(sentence[pos:])
+		}
+		if unicode.IsSpace(char) {
+			if start != -1 {
+				return start, pos
+			}
+		} else if start == -1 {
+			start = pos
+		}
+		pos += size
+	}
+	return start, len(sentence)
 }
 
-func (mq mergeQueue) Swap(i, j int) {
-	mq[i], mq[j] = mq[j], mq[i]
+// EncodeSentence takes a string of space-separated words and tokenizes each word
+// according to the BPE rules. Through encodingConfig one can state whether to add BOS, EOS tokens,
+// whether to reverse the output sequences and whether to apply BPE-dropout. The words can be split
+// differently with WithPreTokenizer. EncodeSentence returns the numerical encoding of the sentence.
+func (m Model) EncodeSentence(sentence string, encodingConfig EncodingConfig,
+) (EncodedString, error) {
+	encodedSentence, _, err := m.encodeSentence(sentence, encodingConfig, false)
+	return encodedSentence, err
 }
 
-func (mq *mergeQueue) Push(x interface{}) {
-	*mq = append(*mq, x.(*mergeEvent))
+// EncodeSentenceWithOffsets works like EncodeSentence and additionally returns the span of bytes
+// in the sentence for every token. The span of the token which starts a word does not include
+// the preceding white space; UNK token spans all the consecutive unknown chars it replaces;
+// BOS and EOS tokens have empty spans at the beginning and at the end of the sentence.
+// The offsets are reversed together with the tokens. With WithNormalizer they refer to
+// the normalized sentence.
+func (m Model) EncodeSentenceWithOffsets(sentence string, encodingConfig EncodingConfig,
+) (EncodedString, []Offset, error) {
+	return m.encodeSentence(sentence, encodingConfig, true)
 }
 
-func (mq *mergeQueue) Pop() interface{} {
-	old := *mq
-	n := len(old)
-	item := old[n-1]
-	old[n-1] = nil // avoid memory leak
-	*mq = old[0 : n-1]
-	return item
+func (m Model) encodeSentence(sentence string, encodingConfig EncodingConfig, withOffsets bool,
+) (EncodedString, []Offset, error) {
+	buffers := getEncodingBuffers()
+	defer putEncodingBuffers(buffers)
+	return m.appendEncoded(buffers, nil, nil, sentence, encodingConfig, withOffsets)
 }
 
-// EncodeSentence takes a string of space-separated words and tokenizes each word
-// according to the BPE rules. Through encodingConfig one can state whether to add BOS, EOS tokens
-// and whether to reverse the output sequences. EncodeSentence returns the numerical encoding
-// of the sentence.
-func (m Model) EncodeSentence(sentence string, encodingConfig EncodingConfig,
-) (EncodedString, error) {
-	var encodedSentence EncodedString
-
+// appendEncoded encodes the sentence using the scratch buffers and appends the ids to
+// encodedSentence and, if withOffsets is set, the spans of the tokens to offsets. Only the
+// appended part is reversed. The slices are returned unchanged on error.
+func (m Model) appendEncoded(buffers *encodingBuffers, encodedSentence EncodedString,
+	offsets []Offset, sentence string, encodingConfig EncodingConfig, withOffsets bool,
+) (EncodedString, []Offset, error) {
+	if encodingConfig.dropout < 0 || encodingConfig.dropout > 1 {
+		logger.Errorf("%v: dropout probability is impossible", encodingConfig.dropout)
+		return encodedSentence, offsets, ErrInvalidDropout
+	}
+	if encodingConfig.bos && m.specialTokens.bos == -1 {
+		logger.Errorf("Cannot use bos - model was trained without it")
+		return encodedSentence, offsets, &SpecialTokenMissingError{bosToken}
+	}
+	if encodingConfig.eos && m.specialTokens.eos == -1 {
+		logger.Errorf("Cannot use eos - model was trained without it")
+		return encodedSentence, offsets, &SpecialTokenMissingError{eosToken}
+	}
+	if encodingConfig.normalizer != nil {
+		sentence = encodingConfig.normalizer.Normalize(sentence)
+	}
+	encodedStart, offsetsStart := len(encodedSentence), len(offsets)
 	if encodingConfig.bos {
-		if m.specialTokens.bos == -1 {
-			logrus.Error("Cannot use bos - model was trained without it")
-			return encodedSentence, errors.New("model was trained withous bos")
-		}
 		encodedSentence = append(encodedSentence, TokenID(m.specialTokens.bos))
+		if withOffsets {
+			offsets = append(offsets, Offset{0, 0})
+		}
 	}
-	for _, word := range strings.Fields(sentence) {
-		if len(word) == 0 {
-			continue
+	cache := encodingConfig.cache
+	if encodingConfig.dropout > 0 {
+		// The encodings of the words are random, they must not be cached
+		cache = nil
+	}
+	wordStart, wordEnd, err := encodingConfig.nextWord(sentence, 0)
+	for err == nil && wordStart != -1 {
+		// White space splitting never produces attached words
+		key := wordKey{sentence[wordStart:wordEnd],
+			encodingConfig.preTokenizer != nil && !followsSpace(sentence, wordStart)}
+		cached := false
+		if cache != nil {
+			encodedSentence, offsets, cached = cache.appendWord(encodedSentence, offsets, key,
+				wordStart, withOffsets)
 		}
-		var encodedWord = []encodingToken{{m.spaceID, -1, 1}}
-		var pendingMerges mergeQueue
-		// Check whether two consecutive tokens can be merged and if so add merge suggestion to
-		// the priority queue
-		pushIfRuleExists := func(leftPos int) {
-			rightPos := encodedWord[leftPos].next
-			ruleCandidate := newTokenIDPair(encodedWord[leftPos].id, encodedWord[rightPos].id)
-			if priority, ok := m.rule2id[ruleCandidate]; ok {
-				heap.Push(&pendingMerges, &mergeEvent{priority, leftPos})
+		if !cached {
+			encodedWord := m.encodeWord(buffers, sentence, wordStart, wordEnd, key.attached,
+				encodingConfig)
+			if cache != nil {
+				buffers.ids, buffers.offsets = buffers.ids[:0], buffers.offsets[:0]
 			}
-		}
-		// Build linked list corresponding to the word's split on known chars and unknown tokens
-		unknownToken := false
-		for _, char := range word {
-			if charID, ok := m.char2id[char]; ok {
-				if unknownToken {
-					encodedWord = append(encodedWord,
-						encodingToken{TokenID(m.specialTokens.unk), len(encodedWord) - 1,
-							len(encodedWord) + 1})
-					unknownToken = false
+			// Retrieve all tokens that are left and append them to the result for the whole
+			// sentence
+			for pos := 0; pos > -1; {
+				token := encodedWord[pos]
+				encodedSentence = append(encodedSentence, token.id)
+				if withOffsets {
+					offsets = append(offsets, Offset{token.start, token.end})
 				}
-				encodedWord = append(encodedWord,
-					encodingToken{charID, len(encodedWord) - 1, len(encodedWord) + 1})
-				pushIfRuleExists(len(encodedWord) - 2)
-			} else {
-				unknownToken = true
-			}
-		}
-		if unknownToken {
-			encodedWord = append(encodedWord,
-				encodingToken{TokenID(m.specialTokens.unk), len(encodedWord) - 1,
-					len(encodedWord) + 1})
-		}
-		encodedWord[len(encodedWord)-1].next = -1
-		// Perform merges of subword tokens in the word according to the BPE model rules
-		for len(pendingMerges) > 0 {
-			event := heap.Pop(&pendingMerges).(*mergeEvent)
-			proposedRule := m.rules[event.priority]
-			leftPos := event.pos
-			leftToken := encodedWord[leftPos]
-			rightPos := leftToken.next
-			if rightPos == -1 {
-				continue
-			}
-			rightToken := encodedWord[rightPos]
-			// Check that the tokens suggested for the merge have not changed
-			if proposedRule.left != leftToken.id || proposedRule.right != rightToken.id {
-				continue
-			}
-			// Create token as a merge of the right and the left ones
-			leftToken.next = rightToken.next
-			leftToken.id = proposedRule.result
-			// Put merged token on the place of the left token
-			encodedWord[leftPos] = leftToken
-			// Put 'empty' token on the place of the right token
-			encodedWord[rightPos] = encodingToken{0, -1, -1}
-			// Add suggestions for merges for the new merged token
-			if rightToken.next != -1 {
-				encodedWord[rightToken.next].prev = leftPos
-				pushIfRuleExists(leftPos)
+				if cache != nil {
+					buffers.ids = append(buffers.ids, token.id)
+					buffers.offsets = append(buffers.offsets,
+						Offset{token.start - wordStart, token.end - wordStart})
+				}
+				pos = token.next
 			}
-			if leftToken.prev != -1 {
-				pushIfRuleExists(leftToken.prev)
+			if cache != nil {
+				cache.put(key, buffers.ids, buffers.offsets)
 			}
 		}
-		// Retrieve all tokens that are left and append them to the result for the whole sentence
-		for pos := 0; pos > -1; {
-			encodedSentence = append(encodedSentence, encodedWord[pos].id)
-			pos = encodedWord[pos].next
-		}
+		wordStart, wordEnd, err = encodingConfig.nextWord(sentence, wordEnd)
+	}
+	if err != nil {
+		return encodedSentence[:encodedStart], offsets[:offsetsStart], err
 	}
 	if encodingConfig.eos {
-		if m.specialTokens.eos == -1 {
-			logrus.Error("Cannot use eos - model was trained without it")
-			return encodedSentence, errors.New("model was trained withous eos")
-		}
 		encodedSentence = append(encodedSentence, TokenID(m.specialTokens.eos))
+		if withOffsets {
+			offsets = append(offsets, Offset{len(sentence), len(sentence)})
+		}
 	}
 	if encodingConfig.reverse {
-		for i := 0; i < len(encodedSentence)/2; i++ {
-			encodedSentence[i], encodedSentence[len(encodedSentence)-i-1] =
-				encodedSentence[len(encodedSentence)-i-1], encodedSentence[i]
+		appended := encodedSentence[encodedStart:]
+		for i := 0; i < len(appended)/2; i++ {
+			appended[i], appended[len(appended)-i-1] = appended[len(appended)-i-1], appended[i]
+		}
+		appendedOffsets := offsets[offsetsStart:]
+		for i := 0; i < len(appendedOffsets)/2; i++ {
+			appendedOffsets[i], appendedOffsets[len(appendedOffsets)-i-1] =
+				appendedOffsets[len(appendedOffsets)-i-1], appendedOffsets[i]
 		}
 	}
-	return encodedSentence, nil
+	return encodedSentence, offsets, nil
+}
+
+// encodeWord splits sentence[wordStart:wordEnd] into chars and merges them according to the BPE
+// rules. The space token is put in front unless the word is attached to the previous one.
+// It returns the linked list of the tokens which starts at the first element.
+func (m Model) encodeWord(buffers *encodingBuffers, sentence string, wordStart, wordEnd int,
+	attached bool, encodingConfig EncodingConfig) []encodingToken {
+	encodedWord := buffers.word[:0]
+	if !attached {
+		encodedWord = append(encodedWord, encodingToken{m.spaceID, -1, 1, wordStart, wordStart})
+	}
+	buffers.queue = buffers.queue[:0]
+	buffers.dropped = buffers.dropped[:0]
+	// Build linked list corresponding to the word's split on known chars and unknown tokens
+	unknownStart := -1
+	for pos, char := range sentence[wordStart:wordEnd] {
+		start := wordStart + pos
+		if charID, ok := m.char2id[char]; ok {
+			if unknownStart != -1 {
+				encodedWord = append(encodedWord,
+					encodingToken{TokenID(m.specialTokens.unk), len(encodedWord) - 1,
+						len(encodedWord) + 1, unknownStart, start})
+				unknownStart = -1
+			}
+			encodedWord = append(encodedWord,
+				encodingToken{charID, len(encodedWord) - 1, len(encodedWord) + 1,
+					start, start + utf8.RuneLen(char)})
+			if len(encodedWord) > 1 {
+				buffers.pushIfRuleExists(m.rule2id, encodedWord, len(encodedWord)-2)
+			}
+		} else if unknownStart == -1 {
+			unknownStart = start
+		}
+	}
+	if unknownStart != -1 {
+		encodedWord = append(encodedWord,
+			encodingToken{TokenID(m.specialTokens.unk), len(encodedWord) - 1,
+				len(encodedWord) + 1, unknownStart, wordEnd})
+	}
+	encodedWord[len(encodedWord)-1].next = -1
+	buffers.word = encodedWord
+	// Perform merges of subword tokens in the word according to the BPE model rules
+	for len(buffers.queue) > 0 {
+		event := buffers.queue.pop()
+		// BPE-dropout: skip the merge on this step, it becomes possible again after the next one
+		if encodingConfig.dropout > 0 && encodingConfig.rand.Float64() < encodingConfig.dropout {
+			buffers.dropped = append(buffers.dropped, event)
+			continue
+		}
+		proposedRule := m.rules[event.priority]
+		leftPos := event.pos
+		leftToken := encodedWord[leftPos]
+		rightPos := leftToken.next
+		if rightPos == -1 {
+			continue
+		}
+		rightToken := encodedWord[rightPos]
+		// Check that the tokens suggested for the merge have not changed
+		if proposedRule.left != leftToken.id || proposedRule.right != rightToken.id {
+			continue
+		}
+		// Create token as a merge of the right and the left ones
+		leftToken.next = rightToken.next
+		leftToken.id = proposedRule.result
+		leftToken.end = rightToken.end
+		// Put merged token on the place of the left token
+		encodedWord[leftPos] = leftToken
+		// Put 'empty' token on the place of the right token
+		encodedWord[rightPos] = encodingToken{0, -1, -1, 0, 0}
+		// Add suggestions for merges for the new merged token
+		if rightToken.next != -1 {
+			encodedWord[rightToken.next].prev = leftPos
+			buffers.pushIfRuleExists(m.rule2id, encodedWord, leftPos)
+		}
+		if leftToken.prev != -1 {
+			buffers.pushIfRuleExists(m.rule2id, encodedWord, leftToken.prev)
+		}
+		for _, dropped := range buffers.dropped {
+			buffers.queue.push(dropped)
+		}
+		buffers.dropped = buffers.dropped[:0]
+	}
+	return encodedWord
 }
 
 // EncodeSentences takes a sequence of strings which consist of space-separated words and tokenizes
@@ -473,18 +903,69 @@ func (m Model) EncodeSentences(sentences []string, encodingConfig EncodingConfig
 // EncodeStream reads a sequence of strings which consist of space-separated words from the given
 // stream and tokenizes each word according to the BPE rules. Through encodingConfig one can state
 // whether to add BOS and EOS tokens (beginning and end of sentence) and whether to reverse the
-// output sequences. EncodeStream returns the numerical encodings of the sentences.
+// output sequences. EncodeStream returns the numerical encodings of the sentences. The lines of
+// the stream are not limited in length; errors which are specific to a line are reported as
+// *LineError.
 func (m Model) EncodeStream(reader io.Reader, encodingConfig EncodingConfig) ([]EncodedString,
 	error) {
-	scanner := bufio.NewScanner(reader)
+	encoder := m.NewStreamEncoder(reader, encodingConfig)
 	var encodedSentence []EncodedString
-	for scanner.Scan() {
-		sentenceIds, err := m.EncodeSentence(scanner.Text(), encodingConfig)
+	for encoder.Next() {
+		encodedSentence = append(encodedSentence, encoder.Encoded())
+	}
+	return encodedSentence, encoder.Err()
+}
+
+// EncodeSentenceToSubwords works like EncodeSentence but returns the subword tokens themselves
+// instead of their ids. Subwords which start words keep the special space char at the beginning.
+func (m Model) EncodeSentenceToSubwords(sentence string, encodingConfig EncodingConfig) ([]string,
+	error) {
+	encodedSentence, err := m.EncodeSentence(sentence, encodingConfig)
+	if err != nil {
+		return nil, err
+	}
+	return m.idsToSubwords(encodedSentence)
+}
+
+// EncodeSentencesToSubwords works like EncodeSentences but returns the subword tokens themselves
+// instead of their ids.
+func (m Model) EncodeSentencesToSubwords(sentences []string, encodingConfig EncodingConfig) (
+	[][]string, error) {
+	subwordSentences := make([][]string, len(sentences))
+	for i, sentence := range sentences {
+		subwords, err := m.EncodeSentenceToSubwords(sentence, encodingConfig)
 		if err != nil {
-			return encodedSentence, err
+			return subwordSentences, err
 		}
-		encodedSentence = append(encodedSentence, sentenceIds)
+		subwordSentences[i] = subwords
 	}
-	err := scanner.Err()
-	return encodedSentence, err
+	return subwordSentences, nil
+}
+
+// EncodeStreamToSubwords works like EncodeStream but returns the subword tokens themselves
+// instead of their ids.
+func (m Model) EncodeStreamToSubwords(reader io.Reader, encodingConfig EncodingConfig) (
+	[][]string, error) {
+	encodedSentences, err := m.EncodeStream(reader, encodingConfig)
+	subwordSentences := make([][]string, 0, len(encodedSentences))
+	for _, encodedSentence := range encodedSentences {
+		subwords, err := m.idsToSubwords(encodedSentence)
+		if err != nil {
+			return subwordSentences, err
+		}
+		subwordSentences = append(subwordSentences, subwords)
+	}
+	return subwordSentences, err
+}
+
+func (m Model) idsToSubwords(encodedSentence EncodedString) ([]string, error) {
+	subwords := make([]string, len(encodedSentence))
+	for i, id := range encodedSentence {
+		subword, err := m.IDToToken(id, false)
+		if err != nil {
+			return subwords, err
+		}
+		subwords[i] = subword
+	}
+	return subwords, nil
 }
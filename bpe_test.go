@@ -2,7 +2,9 @@ package bpe
 
 import (
 	"bytes"
+	"math/rand"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -10,24 +12,59 @@ import (
 
 var BPE = Model{
 	map[rune]TokenID{97: 8, 98: 7, 99: 6, 100: 5, 95: 4},
-	map[TokenID]rune{4: 95, 5: 100, 6: 99, 7: 98, 8: 97},
 	[]rule{{4, 8, 9}, {4, 6, 10}, {4, 5, 11}, {4, 7, 12}, {8, 7, 13}, {8, 8, 14}},
-	map[TokenIDPair]int{TokenIDPair((4 << 32) + 8): 0, TokenIDPair((4 << 32) + 6): 1,
-		TokenIDPair((4 << 32) + 5): 2, TokenIDPair((4 << 32) + 7): 3,
-		TokenIDPair((8 << 32) + 7): 4, TokenIDPair((8 << 32) + 8): 5},
-	map[TokenID]EncodedString{4: {4}, 5: {5}, 6: {6}, 7: {7}, 8: {8}, 9: {4, 8},
-		10: {4, 6}, 11: {4, 5}, 12: {4, 7}, 13: {8, 7}, 14: {8, 8}},
+	newTestPairTable(TokenIDPair((4<<32)+8), TokenIDPair((4<<32)+6), TokenIDPair((4<<32)+5),
+		TokenIDPair((4<<32)+7), TokenIDPair((8<<32)+7), TokenIDPair((8<<32)+8)),
+	[]tokenEntry{absentToken, absentToken, absentToken, absentToken, {95, 0, 0}, {100, 0, 0},
+		{99, 0, 0}, {98, 0, 0}, {97, 0, 0}, {-1, 0, 2}, {-1, 2, 4}, {-1, 4, 6}, {-1, 6, 8},
+		{-1, 8, 10}, {-1, 10, 12}},
+	[]byte("_a_c_d_babaa"),
 	map[string]TokenID{"a": 8, "b": 7, "c": 6, "d": 5, "_": 4, "_a": 9, "_b": 12,
 		"_c": 10, "_d": 11, "ab": 13, "aa": 14, "<PAD>": 0, "<UNK>": 1, "<BOS>": 2, "<EOS>": 3},
+	nil,
 	specialTokens{1, 0, 2, 3},
 	4,
 }
 
+// newTestPairTable creates the table which maps the pairs to their positions
+func newTestPairTable(pairs ...TokenIDPair) pairTable {
+	table := newPairTable(len(pairs))
+	for i, pair := range pairs {
+		table.put(pair, i)
+	}
+	return table
+}
+
 func TestNewModel(t *testing.T) {
 	model := newModel(10)
 	require.Equal(t, 10, len(model.rules))
 }
 
+func TestNewEncodingConfig(t *testing.T) {
+	req := require.New(t)
+	req.Equal(EncodingConfig{}, NewEncodingConfig())
+	req.Equal(EncodingConfig{bos: true}, NewEncodingConfig(WithBOS()))
+	req.Equal(EncodingConfig{bos: true, eos: true, reverse: true},
+		NewEncodingConfig(Reversed(), WithEOS(), WithBOS()))
+	encodingConfig := NewEncodingConfig(WithDropout(0.1, rand.NewSource(7)))
+	req.Equal(0.1, encodingConfig.dropout)
+	req.NotNil(encodingConfig.rand)
+	encodingConfig = NewEncodingConfig(WithDropout(0.1, nil))
+	req.NotNil(encodingConfig.rand)
+}
+
+func TestNewDecodingConfig(t *testing.T) {
+	req := require.New(t)
+	req.Equal(DecodingConfig{}, NewDecodingConfig())
+	req.Equal(DecodingConfig{skipPAD: true, skipUNK: true, skipBOS: true, skipEOS: true},
+		NewDecodingConfig(SkipSpecialTokens()))
+	req.Equal(DecodingConfig{skipPAD: true, skipEOS: true, stopAtEOS: true, reverse: true,
+		unk: "?"}, NewDecodingConfig(SkipPAD(), SkipEOS(), StopAtEOS(), ReversedInput(),
+		WithUNKString("?")))
+	req.Equal(DecodingConfig{skipUNK: true, skipBOS: true}, NewDecodingConfig(SkipUNK(),
+		SkipBOS()))
+}
+
 func TestDecodeToken(t *testing.T) {
 	req := require.New(t)
 	id2char := map[TokenID]rune{1: []rune("a")[0], 2: []rune("b")[0], 3: []rune("c")[0]}
@@ -168,6 +205,80 @@ func TestReadModel(t *testing.T) {
 	req.Error(err)
 }
 
+func TestReadModel_HugeIDs(t *testing.T) {
+	req := require.New(t)
+	_, err := ReadModel(bytes.NewReader([]byte{0, 0, 0, 1, 0, 0, 0, 0,
+		0, 0, 0, 97, 255, 0, 0, 0,
+		0, 0, 0, 1, 0, 0, 0, 0, 255, 255, 255, 255, 255, 255, 255, 255}))
+	req.Error(err)
+
+	_, err = ReadModel(bytes.NewReader([]byte{0, 0, 0, 1, 0, 0, 0, 1,
+		0, 0, 0, 97, 0, 0, 0, 0,
+		0, 0, 0, 0, 0, 0, 0, 0, 255, 0, 0, 0,
+		0, 0, 0, 1, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255}))
+	req.Error(err)
+}
+
+func TestReadModel_SpaceToken(t *testing.T) {
+	req := require.New(t)
+	// "▁" is the space token even if it does not have the lowest char id
+	model, err := ReadModel(bytes.NewReader([]byte{0, 0, 0, 2, 0, 0, 0, 1,
+		0, 0, 0, 97, 0, 0, 0, 4,
+		0, 0, 0x25, 0x81, 0, 0, 0, 5,
+		0, 0, 0, 5, 0, 0, 0, 4, 0, 0, 0, 6,
+		0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 3}))
+	req.NoError(err)
+	req.Equal(TokenID(5), model.spaceID)
+	ids, err := model.EncodeSentence("a a", NewEncodingConfig())
+	req.NoError(err)
+	req.Equal(EncodedString{6, 6}, ids)
+	sentence, err := model.DecodeSentence(EncodedString{5, 4, 6}, NewDecodingConfig())
+	req.NoError(err)
+	req.Equal("a a", sentence)
+
+	// The dumps without "▁" use the char with the lowest id
+	model, err = ReadModel(bytes.NewReader([]byte{0, 0, 0, 2, 0, 0, 0, 0,
+		0, 0, 0, 97, 0, 0, 0, 5,
+		0, 0, 0, 95, 0, 0, 0, 4,
+		0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 3}))
+	req.NoError(err)
+	req.Equal(TokenID(4), model.spaceID)
+}
+
+func TestModel_WriteTo(t *testing.T) {
+	req := require.New(t)
+	expected := []byte{0, 0, 0, 5, 0, 0, 0, 6,
+		0, 0, 0, 95, 0, 0, 0, 4,
+		0, 0, 0, 100, 0, 0, 0, 5,
+		0, 0, 0, 99, 0, 0, 0, 6,
+		0, 0, 0, 98, 0, 0, 0, 7,
+		0, 0, 0, 97, 0, 0, 0, 8,
+		0, 0, 0, 4, 0, 0, 0, 8, 0, 0, 0, 9,
+		0, 0, 0, 4, 0, 0, 0, 6, 0, 0, 0, 10,
+		0, 0, 0, 4, 0, 0, 0, 5, 0, 0, 0, 11,
+		0, 0, 0, 4, 0, 0, 0, 7, 0, 0, 0, 12,
+		0, 0, 0, 8, 0, 0, 0, 7, 0, 0, 0, 13,
+		0, 0, 0, 8, 0, 0, 0, 8, 0, 0, 0, 14,
+		0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 3}
+	var buf bytes.Buffer
+	n, err := BPE.WriteTo(&buf)
+	req.NoError(err)
+	req.Equal(int64(len(expected)), n)
+	req.Equal(expected, buf.Bytes())
+
+	model, err := ReadModel(&buf)
+	req.NoError(err)
+	req.Equal(BPE, *model)
+
+	model = newModel(0)
+	model.setSpecialTokens(specialTokens{0, -1, -1, -1})
+	buf.Reset()
+	_, err = model.WriteTo(&buf)
+	req.NoError(err)
+	req.Equal([]byte{0, 0, 0, 0, 0, 0, 0, 0,
+		0, 0, 0, 0, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255}, buf.Bytes())
+}
+
 func TestModel_IDToToken(t *testing.T) {
 	req := require.New(t)
 	token, err := BPE.IDToToken(11, false)
@@ -192,31 +303,107 @@ func TestModel_IDToToken(t *testing.T) {
 
 func TestModel_DecodeSentence(t *testing.T) {
 	req := require.New(t)
-	sentence, err := BPE.DecodeSentence(EncodedString{2, 10, 7, 12, 6, 6, 11, 9, 13, 3, 0})
+	sentence, err := BPE.DecodeSentence(EncodedString{2, 10, 7, 12, 6, 6, 11, 9, 13, 3, 0},
+		NewDecodingConfig())
 	req.NoError(err)
 	req.Equal("<BOS>cb bcc d aab<EOS><PAD>", sentence)
 
-	sentence, err = BPE.DecodeSentence(EncodedString{12, 8, 6, 5, 11, 6, 9, 9, 5, 5, 8, 11, 7})
+	sentence, err = BPE.DecodeSentence(EncodedString{12, 8, 6, 5, 11, 6, 9, 9, 5, 5, 8, 11, 7},
+		NewDecodingConfig())
 	req.NoError(err)
 	req.Equal("bacd dc a adda db", sentence)
 
-	sentence, err = BPE.DecodeSentence(EncodedString{12, 8, 25, 5, 11, 6, 9, 9, 5, 5, 8, 11, 7})
+	sentence, err = BPE.DecodeSentence(EncodedString{12, 8, 25, 5, 11, 6, 9, 9, 5, 5, 8, 11, 7},
+		NewDecodingConfig())
 	req.Error(err)
 }
 
+func TestModel_DecodeSentence_EdgeCases(t *testing.T) {
+	req := require.New(t)
+	cases := []struct {
+		encoded  EncodedString
+		expected string
+		skipped  string
+	}{
+		{EncodedString{}, "", ""},
+		{nil, "", ""},
+		{EncodedString{0}, "<PAD>", ""},
+		{EncodedString{0, 0, 0}, "<PAD><PAD><PAD>", ""},
+		{EncodedString{2}, "<BOS>", ""},
+		{EncodedString{2, 3}, "<BOS><EOS>", ""},
+		{EncodedString{4}, "", ""},
+		{EncodedString{8}, "a", "a"},
+		{EncodedString{0, 2, 9, 3}, "<PAD><BOS>a<EOS>", "a"},
+		{EncodedString{9, 3, 12, 2, 10}, "a<EOS> b<BOS> c", "a b c"},
+		{EncodedString{4, 1, 9}, "<UNK> a", " a"},
+		{EncodedString{3, 7, 9, 2}, "<EOS>b a<BOS>", "b a"},
+		{EncodedString{1, 9, 2}, "<UNK> a<BOS>", "a"},
+	}
+	for _, c := range cases {
+		sentence, err := BPE.DecodeSentence(c.encoded, NewDecodingConfig())
+		req.NoError(err)
+		req.Equal(c.expected, sentence, "%v", c.encoded)
+		sentence, err = BPE.DecodeSentence(c.encoded, NewDecodingConfig(SkipSpecialTokens()))
+		req.NoError(err)
+		req.Equal(c.skipped, sentence, "%v", c.encoded)
+	}
+
+	model := BPE
+	model.specialTokens.eos = -1
+	_, err := model.DecodeSentence(EncodedString{9, 3}, NewDecodingConfig())
+	req.Error(err)
+}
+
+func TestModel_DecodeSentence_Options(t *testing.T) {
+	req := require.New(t)
+	encoded := EncodedString{2, 10, 7, 12, 1, 11, 9, 3, 9, 0, 0}
+	cases := []struct {
+		decodingConfig DecodingConfig
+		expected       string
+	}{
+		{NewDecodingConfig(), "<BOS>cb b<UNK> d a<EOS> a<PAD><PAD>"},
+		{NewDecodingConfig(SkipPAD()), "<BOS>cb b<UNK> d a<EOS> a"},
+		{NewDecodingConfig(SkipBOS(), SkipUNK()), "cb b d a<EOS> a<PAD><PAD>"},
+		{NewDecodingConfig(StopAtEOS()), "<BOS>cb b<UNK> d a<EOS>"},
+		{NewDecodingConfig(StopAtEOS(), SkipEOS(), SkipBOS()), "cb b<UNK> d a"},
+		{NewDecodingConfig(WithUNKString("??")), "<BOS>cb b?? d a<EOS> a<PAD><PAD>"},
+		{NewDecodingConfig(SkipSpecialTokens(), WithUNKString("??")), "cb b d a a"},
+	}
+	for _, c := range cases {
+		sentence, err := BPE.DecodeSentence(encoded, c.decodingConfig)
+		req.NoError(err)
+		req.Equal(c.expected, sentence)
+	}
+
+	sentence := "abcda bdhsab acad"
+	encoded, err := BPE.EncodeSentence(sentence, NewEncodingConfig(WithBOS(), WithEOS(),
+		Reversed()))
+	req.NoError(err)
+	padded := append(EncodedString{0, 0}, encoded...)
+	decoded, err := BPE.DecodeSentence(padded, NewDecodingConfig(ReversedInput(), StopAtEOS(),
+		SkipBOS(), SkipEOS(), WithUNKString("h")))
+	req.NoError(err)
+	req.Equal("abcda bdhab acad", decoded)
+	req.Equal(EncodedString{0, 0}, padded[:2])
+
+	decoded, err = BPE.DecodeSentence(EncodedString{1, 9}, NewDecodingConfig(SkipUNK()))
+	req.NoError(err)
+	req.Equal("a", decoded)
+}
+
 func TestModel_DecodeSentences(t *testing.T) {
 	req := require.New(t)
 	encodedSentences := []EncodedString{
 		{2, 10, 7, 12, 6, 6, 11, 9, 13, 3, 0},
 		{12, 8, 6, 5, 11, 6, 9, 9, 5, 5, 8, 11, 7}}
-	sentences, err := BPE.DecodeSentences(encodedSentences)
+	sentences, err := BPE.DecodeSentences(encodedSentences, NewDecodingConfig())
 	req.NoError(err)
 	req.Equal([]string{"<BOS>cb bcc d aab<EOS><PAD>", "bacd dc a adda db"}, sentences)
 
 	encodedSentences = []EncodedString{
 		{2, 10, 7, 12, 6, 6, 11, 9, 8, 7, 3, 0},
 		{12, 8, 6, 5, 30, 6, 9, 9, 5, 5, 8, 11, 7}}
-	sentences, err = BPE.DecodeSentences(encodedSentences)
+	sentences, err = BPE.DecodeSentences(encodedSentences, NewDecodingConfig())
 	req.Error(err)
 }
 
@@ -224,55 +411,104 @@ func TestModel_DecodeFromStream(t *testing.T) {
 	req := require.New(t)
 	reader := strings.NewReader(`2 10 7 12 6 6 11 9 13 3 0
 12 8 6 5 11 6 9 9 5 5 8 11 7`)
-	sentences, err := BPE.DecodeFromStream(reader)
+	sentences, err := BPE.DecodeFromStream(reader, NewDecodingConfig())
 	req.NoError(err)
 	req.Equal([]string{"<BOS>cb bcc d aab<EOS><PAD>", "bacd dc a adda db"}, sentences)
 
 	reader = strings.NewReader(`2 20 7 12 6 6 11 9 8 7 3 0
 12 8 6 5 11 6 9 9 5 5 8 11 7`)
-	sentences, err = BPE.DecodeFromStream(reader)
+	sentences, err = BPE.DecodeFromStream(reader, NewDecodingConfig())
 	req.Error(err)
 }
 
 func TestModel_EncodeSentence(t *testing.T) {
 	req := require.New(t)
 	ids, err := BPE.EncodeSentence("abcda bdhsab acad aaab baaaab",
-		EncodingConfig{true, true, false})
+		NewEncodingConfig(WithBOS(), WithEOS()))
 	req.NoError(err)
 	req.Equal(EncodedString{2, 9, 7, 6, 5, 8, 12, 5, 1, 13, 9, 6, 8, 5, 9, 8, 13, 12, 14, 8, 13,
 		3}, ids)
 
 	ids, err = BPE.EncodeSentence("gjhcbsd kbs;.jakjcdljk ajbabk,l kjaajlkj kj",
-		EncodingConfig{false, false, false})
+		NewEncodingConfig())
 	req.NoError(err)
 	req.Equal(EncodedString{4, 1, 6, 7, 1, 5, 4, 1, 7, 1, 8, 1, 6, 5, 1, 9, 1, 7, 13, 1, 4, 1, 14,
 		1, 4, 1}, ids)
 
 	ids, err = BPE.EncodeSentence("gjhcbsd kbs;.jakjcdljk ajbabk,l kjaajlkj kj",
-		EncodingConfig{false, false, true})
+		NewEncodingConfig(Reversed()))
 	req.NoError(err)
 	req.Equal(EncodedString{
 		1, 4, 1, 14, 1, 4, 1, 13, 7, 1, 9, 1, 5, 6, 1, 8, 1, 7, 1, 4, 5, 1, 7, 6, 1, 4}, ids)
 
 	ids, err = BPE.EncodeSentence("gjhcbsd kbs;.jakjcdljk ajbabk,l kjaajlkj kja",
-		EncodingConfig{false, false, true})
+		NewEncodingConfig(Reversed()))
 	req.NoError(err)
 	req.Equal(EncodedString{
 		8, 1, 4, 1, 14, 1, 4, 1, 13, 7, 1, 9, 1, 5, 6, 1, 8, 1, 7, 1, 4, 5, 1, 7, 6, 1, 4}, ids)
 
 	ids, err = BPE.EncodeSentence("ac bdbc bcdcabcacc abaaadbdcaba",
-		EncodingConfig{false, false, false})
+		NewEncodingConfig())
 	req.NoError(err)
-	restored, err := BPE.DecodeSentence(ids)
+	restored, err := BPE.DecodeSentence(ids, NewDecodingConfig())
 	req.NoError(err)
 	req.Equal("ac bdbc bcdcabcacc abaaadbdcaba", restored)
 }
 
+func TestModel_EncodeSentence_Dropout(t *testing.T) {
+	req := require.New(t)
+	sentence := "abcda bdhsab acad aaab baaaab"
+	expected, err := BPE.EncodeSentence(sentence, NewEncodingConfig())
+	req.NoError(err)
+	ids, err := BPE.EncodeSentence(sentence, NewEncodingConfig(WithDropout(0, nil)))
+	req.NoError(err)
+	req.Equal(expected, ids)
+
+	ids, err = BPE.EncodeSentence(sentence, NewEncodingConfig(WithDropout(1, nil)))
+	req.NoError(err)
+	req.Equal(EncodedString{4, 8, 7, 6, 5, 8, 4, 7, 5, 1, 8, 7, 4, 8, 6, 8, 5, 4, 8, 8, 8, 7,
+		4, 7, 8, 8, 8, 8, 7}, ids)
+
+	first, err := BPE.EncodeSentence(sentence,
+		NewEncodingConfig(WithDropout(0.5, rand.NewSource(42))))
+	req.NoError(err)
+	second, err := BPE.EncodeSentence(sentence,
+		NewEncodingConfig(WithDropout(0.5, rand.NewSource(42))))
+	req.NoError(err)
+	req.Equal(first, second)
+	restored, err := BPE.DecodeSentence(first, NewDecodingConfig())
+	req.NoError(err)
+	req.Equal("abcda bd<UNK>ab acad aaab baaaab", restored)
+
+	_, err = BPE.EncodeSentence(sentence, NewEncodingConfig(WithDropout(1.5, nil)))
+	req.Error(err)
+}
+
+func TestWithDropout_SharedOption(t *testing.T) {
+	// Run with -race: the configurations which share the option must not race on the source
+	for _, source := range []rand.Source{rand.NewSource(3), nil} {
+		option := WithDropout(0.5, source)
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				encodingConfig := NewEncodingConfig(option)
+				for j := 0; j < 100; j++ {
+					_, err := BPE.EncodeSentence("abcda bdhsab acad aaab baaaab", encodingConfig)
+					require.NoError(t, err)
+				}
+			}()
+		}
+		wg.Wait()
+	}
+}
+
 func TestModel_EncodeSentences(t *testing.T) {
 	req := require.New(t)
 	ids, err := BPE.EncodeSentences([]string{"abcda bdhsab acad aaab baaaab",
 		"gjhcbsd kbs;.jakjcdljk ajbabk,l kjaajlkj kj"},
-		EncodingConfig{true, true, false})
+		NewEncodingConfig(WithBOS(), WithEOS()))
 	req.NoError(err)
 	req.Equal([]EncodedString{{2, 9, 7, 6, 5, 8, 12, 5, 1, 13, 9, 6, 8, 5, 9, 8, 13, 12, 14, 8, 13,
 		3}, {2, 4, 1, 6, 7, 1, 5, 4, 1, 7, 1, 8, 1, 6, 5, 1, 9, 1, 7, 13, 1, 4, 1, 14, 1, 4, 1,
@@ -280,9 +516,9 @@ func TestModel_EncodeSentences(t *testing.T) {
 
 	ids, err = BPE.EncodeSentences([]string{"abcda bdab acad aaab baaaab",
 		"abcdbcbd bdbca bbaacbd"},
-		EncodingConfig{false, false, false})
+		NewEncodingConfig())
 	req.NoError(err)
-	restored, err := BPE.DecodeSentences(ids)
+	restored, err := BPE.DecodeSentences(ids, NewDecodingConfig())
 	req.NoError(err)
 	req.Equal([]string{"abcda bdab acad aaab baaaab", "abcdbcbd bdbca bbaacbd"}, restored)
 }
@@ -291,7 +527,7 @@ func TestModel_EncodeStream(t *testing.T) {
 	req := require.New(t)
 	reader := strings.NewReader(`abcda bdhsab acad aaab baaaab
 gjhcbsd kbs;.jakjcdljk ajbabk,l kjaajlkj kj`)
-	ids, err := BPE.EncodeStream(reader, EncodingConfig{true, true, false})
+	ids, err := BPE.EncodeStream(reader, NewEncodingConfig(WithBOS(), WithEOS()))
 	req.NoError(err)
 	req.Equal([]EncodedString{{2, 9, 7, 6, 5, 8, 12, 5, 1, 13, 9, 6, 8, 5, 9, 8, 13, 12, 14, 8, 13,
 		3}, {2, 4, 1, 6, 7, 1, 5, 4, 1, 7, 1, 8, 1, 6, 5, 1, 9, 1, 7, 13, 1, 4, 1, 14, 1, 4, 1,
@@ -299,9 +535,179 @@ gjhcbsd kbs;.jakjcdljk ajbabk,l kjaajlkj kj`)
 
 	reader = strings.NewReader(`abcda bdab acad aaab baaaab
 abcdbcbd bdbca bbaacbd`)
-	ids, err = BPE.EncodeStream(reader, EncodingConfig{false, false, false})
+	ids, err = BPE.EncodeStream(reader, NewEncodingConfig())
 	req.NoError(err)
-	restored, err := BPE.DecodeSentences(ids)
+	restored, err := BPE.DecodeSentences(ids, NewDecodingConfig())
 	req.NoError(err)
 	req.Equal([]string{"abcda bdab acad aaab baaaab", "abcdbcbd bdbca bbaacbd"}, restored)
 }
+
+func TestModel_EncodeSentenceToSubwords(t *testing.T) {
+	req := require.New(t)
+	subwords, err := BPE.EncodeSentenceToSubwords("abcda bdhsab", NewEncodingConfig(WithBOS(),
+		WithEOS()))
+	req.NoError(err)
+	req.Equal([]string{"<BOS>", "_a", "b", "c", "d", "a", "_b", "d", "<UNK>", "ab", "<EOS>"},
+		subwords)
+
+	subwords, err = BPE.EncodeSentenceToSubwords("aab ca", NewEncodingConfig(Reversed()))
+	req.NoError(err)
+	req.Equal([]string{"a", "_c", "ab", "_a"}, subwords)
+
+	subwords, err = BPE.EncodeSentenceToSubwords("", NewEncodingConfig())
+	req.NoError(err)
+	req.Empty(subwords)
+}
+
+func TestModel_EncodeSentencesToSubwords(t *testing.T) {
+	req := require.New(t)
+	subwords, err := BPE.EncodeSentencesToSubwords([]string{"abcda", "bdhsab"},
+		NewEncodingConfig(WithEOS()))
+	req.NoError(err)
+	req.Equal([][]string{{"_a", "b", "c", "d", "a", "<EOS>"},
+		{"_b", "d", "<UNK>", "ab", "<EOS>"}}, subwords)
+
+	_, err = BPE.EncodeSentencesToSubwords([]string{"abcda", "bdhsab"},
+		NewEncodingConfig(WithDropout(2, nil)))
+	req.Error(err)
+}
+
+func TestModel_EncodeStreamToSubwords(t *testing.T) {
+	req := require.New(t)
+	reader := strings.NewReader(`abcda
+bdhsab`)
+	subwords, err := BPE.EncodeStreamToSubwords(reader, NewEncodingConfig(WithBOS()))
+	req.NoError(err)
+	req.Equal([][]string{{"<BOS>", "_a", "b", "c", "d", "a"},
+		{"<BOS>", "_b", "d", "<UNK>", "ab"}}, subwords)
+}
+
+func TestNextWord(t *testing.T) {
+	req := require.New(t)
+	words := func(sentence string) []Offset {
+		var offsets []Offset
+		for start, end := nextWord(sentence, 0); start != -1; start, end = nextWord(sentence, end) {
+			offsets = append(offsets, Offset{start, end})
+		}
+		return offsets
+	}
+	req.Equal([]Offset{{1, 6}, {8, 14}}, words(" abcda \tbdhsab"))
+	req.Equal([]Offset{{0, 4}, {7, 10}}, words("aéb  bcd\n"))
+	req.Equal([]Offset{{0, 1}, {3, 4}, {7, 9}}, words("a\u00a0b\u2003cd"))
+	req.Empty(words(" \t\n"))
+	req.Empty(words(""))
+}
+
+func TestModel_EncodeSentenceWithOffsets(t *testing.T) {
+	req := require.New(t)
+	sentence := " abcda  bdhsab"
+	ids, offsets, err := BPE.EncodeSentenceWithOffsets(sentence,
+		NewEncodingConfig(WithBOS(), WithEOS()))
+	req.NoError(err)
+	expected, err := BPE.EncodeSentence(sentence, NewEncodingConfig(WithBOS(), WithEOS()))
+	req.NoError(err)
+	req.Equal(expected, ids)
+	req.Equal([]Offset{{0, 0}, {1, 2}, {2, 3}, {3, 4}, {4, 5}, {5, 6}, {8, 9}, {9, 10}, {10, 12},
+		{12, 14}, {14, 14}}, offsets)
+
+	ids, offsets, err = BPE.EncodeSentenceWithOffsets("aéé dab", NewEncodingConfig(Reversed()))
+	req.NoError(err)
+	req.Equal(EncodedString{13, 11, 1, 9}, ids)
+	req.Equal([]Offset{{7, 9}, {6, 7}, {1, 5}, {0, 1}}, offsets)
+
+	ids, offsets, err = BPE.EncodeSentenceWithOffsets("", NewEncodingConfig())
+	req.NoError(err)
+	req.Empty(ids)
+	req.Empty(offsets)
+}
+
+// benchmarkCorpus generates a deterministic text with Zipf-distributed words, which resembles
+// natural language enough for benchmarking.
+func benchmarkCorpus(nLines int) []string {
+	random := rand.New(rand.NewSource(1))
+	syllables := []string{"ka", "lo", "mi", "ne", "su", "ta", "ri", "po", "ve", "du", "ch", "é",
+		"ж", "qu", "st", "ng"}
+	words := make([]string, 5000)
+	for i := range words {
+		word := ""
+		for n := 1 + random.Intn(5); n > 0; n-- {
+			word += syllables[random.Intn(len(syllables))]
+		}
+		words[i] = word
+	}
+	zipf := rand.NewZipf(random, 1.1, 1, uint64(len(words)-1))
+	lines := make([]string, nLines)
+	for i := range lines {
+		line := make([]string, 5+random.Intn(20))
+		for j := range line {
+			line[j] = words[zipf.Uint64()]
+		}
+		lines[i] = strings.Join(line, " ")
+	}
+	return lines
+}
+
+var benchmarkModel struct {
+	once  sync.Once
+	model *Model
+	dump  []byte
+	lines []string
+}
+
+func getBenchmarkModel(b testing.TB) (*Model, []byte, []string) {
+	benchmarkModel.once.Do(func() {
+		benchmarkModel.lines = benchmarkCorpus(20000)
+		model, err := Train(strings.NewReader(strings.Join(benchmarkModel.lines, "\n")), 8000,
+			DefaultTrainOptions())
+		if err != nil {
+			b.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if _, err := model.WriteTo(&buf); err != nil {
+			b.Fatal(err)
+		}
+		benchmarkModel.model = model
+		benchmarkModel.dump = buf.Bytes()
+	})
+	return benchmarkModel.model, benchmarkModel.dump, benchmarkModel.lines
+}
+
+func BenchmarkReadModel(b *testing.B) {
+	_, dump, _ := getBenchmarkModel(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ReadModel(bytes.NewReader(dump)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkModel_EncodeSentence(b *testing.B) {
+	model, _, lines := getBenchmarkModel(b)
+	encodingConfig := NewEncodingConfig()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := model.EncodeSentence(lines[i%len(lines)], encodingConfig); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkModel_DecodeSentence(b *testing.B) {
+	model, _, lines := getBenchmarkModel(b)
+	encodedSentences, err := model.EncodeSentences(lines[:1000], NewEncodingConfig())
+	if err != nil {
+		b.Fatal(err)
+	}
+	decodingConfig := NewDecodingConfig()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := model.DecodeSentence(encodedSentences[i%len(encodedSentences)], decodingConfig)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
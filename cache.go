@@ -0,0 +1,169 @@
+package bpe
+
+import "sync"
+
+// WordCache is a bounded cache of the encodings of single words which lets the encoding skip
+// the merges of the words it has already seen. When full, it evicts the least recently used
+// word. WordCache is safe for concurrent use, so one cache can be shared by all the goroutines
+// which encode with the same model. The encodings depend on the model, so a cache must not be
+// shared between different models. It is enabled by WithWordCache.
+type WordCache struct {
+	lock     sync.Mutex
+	capacity int
+	index    map[wordKey]int
+	entries  []cacheEntry
+	// head is the most recently used entry and tail is the least recently used one
+	head   int
+	tail   int
+	hits   uint64
+	misses uint64
+}
+
+// cacheEntry is a node of the doubly linked list of the cached words which is kept in the order
+// of their use.
+type cacheEntry struct {
+	key wordKey
+	ids []TokenID
+	// offsets are relative to the beginning of the word
+	offsets []Offset
+	prev    int
+	next    int
+}
+
+// CacheStats is a snapshot of the usage of WordCache
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+	// Len is the number of the cached words
+	Len int
+}
+
+// NewWordCache creates WordCache which holds at most capacity words. A cache with
+// non-positive capacity stores nothing.
+func NewWordCache(capacity int) *WordCache {
+	if capacity < 0 {
+		capacity = 0
+	}
+	return &WordCache{
+		capacity: capacity,
+		index:    make(map[wordKey]int),
+		head:     -1,
+		tail:     -1,
+	}
+}
+
+// WithWordCache makes the encoding look up the words in the cache before merging them and store
+// the encodings of the new words there. The output is the same as without the cache. The cache
+// is not used together with BPE-dropout, since the encodings of the words are random then.
+func WithWordCache(cache *WordCache) EncodingOption {
+	return func(encodingConfig *EncodingConfig) {
+		encodingConfig.cache = cache
+	}
+}
+
+// Stats returns the number of hits and misses of the lookups and the current size of the cache
+func (c *WordCache) Stats() CacheStats {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return CacheStats{Hits: c.hits, Misses: c.misses, Len: len(c.index)}
+}
+
+// Purge removes all the words from the cache and resets the statistics
+func (c *WordCache) Purge() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.index = make(map[wordKey]int)
+	c.entries = nil
+	c.head, c.tail = -1, -1
+	c.hits, c.misses = 0, 0
+}
+
+// appendWord appends the cached encoding of the word which starts at wordStart of the sentence
+// to encodedSentence and, if withOffsets is set, its offsets to offsets. ok is false if the word
+// is not cached.
+func (c *WordCache) appendWord(encodedSentence EncodedString, offsets []Offset, key wordKey,
+	wordStart int, withOffsets bool) (EncodedString, []Offset, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	pos, ok := c.index[key]
+	if !ok {
+		c.misses++
+		return encodedSentence, offsets, false
+	}
+	c.hits++
+	c.moveToFront(pos)
+	entry := &c.entries[pos]
+	encodedSentence = append(encodedSentence, entry.ids...)
+	if withOffsets {
+		for _, offset := range entry.offsets {
+			offsets = append(offsets, Offset{wordStart + offset.Start, wordStart + offset.End})
+		}
+	}
+	return encodedSentence, offsets, true
+}
+
+// put stores the encoding of the word together with the offsets of its tokens relative to
+// the beginning of the word. Both slices are copied.
+func (c *WordCache) put(key wordKey, ids []TokenID, offsets []Offset) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.capacity == 0 {
+		return
+	}
+	if _, ok := c.index[key]; ok {
+		// Another goroutine has encoded the same word meanwhile
+		return
+	}
+	var pos int
+	if len(c.entries) < c.capacity {
+		pos = len(c.entries)
+		c.entries = append(c.entries, cacheEntry{prev: -1, next: -1})
+	} else {
+		// Reuse the least recently used entry
+		pos = c.tail
+		c.unlink(pos)
+		delete(c.index, c.entries[pos].key)
+	}
+	entry := &c.entries[pos]
+	// The word is copied so that it does not keep the whole sentence in memory
+	entry.key = wordKey{string([]byte(key.word)), key.attached}
+	entry.ids = append(entry.ids[:0], ids...)
+	entry.offsets = append(entry.offsets[:0], offsets...)
+	c.index[entry.key] = pos
+	c.pushFront(pos)
+}
+
+func (c *WordCache) moveToFront(pos int) {
+	if c.head == pos {
+		return
+	}
+	c.unlink(pos)
+	c.pushFront(pos)
+}
+
+func (c *WordCache) unlink(pos int) {
+	entry := &c.entries[pos]
+	if entry.prev != -1 {
+		c.entries[entry.prev].next = entry.next
+	} else {
+		c.head = entry.next
+	}
+	if entry.next != -1 {
+		c.entries[entry.next].prev = entry.prev
+	} else {
+		c.tail = entry.prev
+	}
+	entry.prev, entry.next = -1, -1
+}
+
+func (c *WordCache) pushFront(pos int) {
+	entry := &c.entries[pos]
+	entry.prev, entry.next = -1, c.head
+	if c.head != -1 {
+		c.entries[c.head].prev = pos
+	}
+	c.head = pos
+	if c.tail == -1 {
+		c.tail = pos
+	}
+}
@@ -0,0 +1,114 @@
+package bpe
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWordCache(t *testing.T) {
+	req := require.New(t)
+	cache := NewWordCache(2)
+	ids, offsets, ok := cache.appendWord(nil, nil, wordKey{"ab", false}, 0, true)
+	req.False(ok)
+	req.Nil(ids)
+	req.Nil(offsets)
+
+	cache.put(wordKey{"ab", false}, []TokenID{9}, []Offset{{0, 2}})
+	cache.put(wordKey{"cd", false}, []TokenID{3, 4}, []Offset{{0, 1}, {1, 2}})
+	ids, offsets, ok = cache.appendWord(EncodedString{1}, []Offset{{0, 0}},
+		wordKey{"ab", false}, 5, true)
+	req.True(ok)
+	req.Equal(EncodedString{1, 9}, ids)
+	req.Equal([]Offset{{0, 0}, {5, 7}}, offsets)
+
+	// "cd" is the least recently used word now
+	cache.put(wordKey{"ef", false}, []TokenID{5}, []Offset{{0, 2}})
+	_, _, ok = cache.appendWord(nil, nil, wordKey{"cd", false}, 0, false)
+	req.False(ok)
+	ids, offsets, ok = cache.appendWord(nil, nil, wordKey{"ef", false}, 0, false)
+	req.True(ok)
+	req.Equal(EncodedString{5}, ids)
+	req.Nil(offsets)
+	_, _, ok = cache.appendWord(nil, nil, wordKey{"ab", false}, 0, false)
+	req.True(ok)
+	req.Equal(CacheStats{Hits: 3, Misses: 2, Len: 2}, cache.Stats())
+
+	cache.Purge()
+	req.Equal(CacheStats{}, cache.Stats())
+	_, _, ok = cache.appendWord(nil, nil, wordKey{"ab", false}, 0, false)
+	req.False(ok)
+
+	cache = NewWordCache(0)
+	cache.put(wordKey{"ab", false}, []TokenID{9}, []Offset{{0, 2}})
+	_, _, ok = cache.appendWord(nil, nil, wordKey{"ab", false}, 0, false)
+	req.False(ok)
+	req.Equal(CacheStats{Misses: 1}, cache.Stats())
+}
+
+func TestModel_EncodeSentenceWithWordCache(t *testing.T) {
+	req := require.New(t)
+	sentences := randomSentences(1000)
+	for _, options := range [][]EncodingOption{
+		nil,
+		{WithBOS(), WithEOS(), Reversed()},
+	} {
+		cache := NewWordCache(50)
+		cachedConfig := NewEncodingConfig(append(options, WithWordCache(cache))...)
+		encodingConfig := NewEncodingConfig(options...)
+		for _, sentence := range sentences {
+			expected, err := BPE.EncodeSentence(sentence, encodingConfig)
+			req.NoError(err)
+			ids, err := BPE.EncodeSentence(sentence, cachedConfig)
+			req.NoError(err)
+			req.Equal(expected, ids)
+
+			expected, expectedOffsets, err := BPE.EncodeSentenceWithOffsets(sentence,
+				encodingConfig)
+			req.NoError(err)
+			ids, offsets, err := BPE.EncodeSentenceWithOffsets(sentence, cachedConfig)
+			req.NoError(err)
+			req.Equal(expected, ids)
+			req.Equal(expectedOffsets, offsets)
+		}
+		stats := cache.Stats()
+		req.NotZero(stats.Hits)
+		req.NotZero(stats.Misses)
+		req.Equal(50, stats.Len)
+	}
+
+	cache := NewWordCache(10)
+	_, err := BPE.EncodeSentence("aab aab", NewEncodingConfig(WithWordCache(cache),
+		WithDropout(0.5, rand.NewSource(1))))
+	req.NoError(err)
+	req.Equal(CacheStats{}, cache.Stats())
+}
+
+func TestModel_EncodeSentencesParallelWithWordCache(t *testing.T) {
+	req := require.New(t)
+	sentences := randomSentences(2000)
+	expected, err := BPE.EncodeSentences(sentences, NewEncodingConfig(WithEOS()))
+	req.NoError(err)
+	cache := NewWordCache(100)
+	ids, err := BPE.EncodeSentencesParallel(context.Background(), sentences,
+		NewEncodingConfig(WithEOS(), WithWordCache(cache)), 8)
+	req.NoError(err)
+	req.Equal(expected, ids)
+	stats := cache.Stats()
+	req.NotZero(stats.Hits)
+	req.Equal(100, stats.Len)
+}
+
+func BenchmarkModel_EncodeSentenceWithWordCache(b *testing.B) {
+	model, _, lines := getBenchmarkModel(b)
+	encodingConfig := NewEncodingConfig(WithWordCache(NewWordCache(10000)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := model.EncodeSentence(lines[i%len(lines)], encodingConfig); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
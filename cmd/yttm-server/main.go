@@ -0,0 +1,206 @@
+// Command yttm-server serves BPE models over HTTP with JSON bodies, see package server for
+// the endpoints, and optionally over gRPC with the service of package tokenizerpb:
+//
+//	yttm-server --addr :8080 --grpc_addr :9090 --model en=en.yttm --model de=de.yttm
+//
+// The name of the model defaults to the base name of its file without the extension.
+// Alternatively, --model_dir serves all the *.yttm files of a directory and reloads them when
+// they change, see bpe.Registry.
+// On SIGINT or SIGTERM /readyz starts failing, the server keeps serving for --drain_delay, so
+// that the load balancers notice it, then stops accepting new connections and waits for
+// the running requests to finish.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+
+	bpe "github.com/src-d/go-YouTokenToMe"
+	"github.com/src-d/go-YouTokenToMe/server"
+)
+
+func main() {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		cancel()
+	}()
+	os.Exit(run(ctx, os.Args[1:], os.Stderr))
+}
+
+// modelPaths collects the repeated --model flags
+type modelPaths []string
+
+func (mp *modelPaths) String() string {
+	return strings.Join(*mp, ",")
+}
+
+func (mp *modelPaths) Set(value string) error {
+	*mp = append(*mp, value)
+	return nil
+}
+
+// run serves until ctx is done and returns the exit code
+func run(ctx context.Context, args []string, stderr io.Writer) int {
+	flags := flag.NewFlagSet("yttm-server", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	addr := flags.String("addr", ":8080", "address to listen on")
+	grpcAddr := flags.String("grpc_addr", "", "address to serve gRPC on, disabled if empty")
+	var paths modelPaths
+	flags.Var(&paths, "model", "[name=]path of a BPE model, may be repeated")
+	modelDir := flags.String("model_dir", "",
+		"directory of the models to serve and reload on changes instead of --model")
+	pollInterval := flags.Duration("poll_interval", bpe.DefaultPollInterval,
+		"how often --model_dir is checked for changes")
+	maxBodyBytes := flags.Int64("max_body_bytes", server.DefaultMaxBodyBytes,
+		"maximal size of the request bodies")
+	drainDelay := flags.Duration("drain_delay", 0,
+		"time to keep serving with failing /readyz before the shutdown")
+	shutdownTimeout := flags.Duration("shutdown_timeout", 30*time.Second,
+		"time to wait for the running requests on shutdown")
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	var handler *server.Server
+	serverOption := server.WithMaxBodyBytes(*maxBodyBytes)
+	if *modelDir != "" {
+		if len(paths) != 0 {
+			fmt.Fprintln(stderr, "yttm-server: --model and --model_dir are mutually exclusive")
+			return 2
+		}
+		registry, err := bpe.NewRegistry(*modelDir, bpe.WithPollInterval(*pollInterval))
+		if err != nil {
+			fmt.Fprintln(stderr, "yttm-server:", err)
+			return 1
+		}
+		defer registry.Close()
+		handler = server.NewWithRegistry(registry, serverOption)
+	} else {
+		files, err := loadModels(paths)
+		if err != nil {
+			fmt.Fprintln(stderr, "yttm-server:", err)
+			return 1
+		}
+		handler = server.NewWithModelFiles(files, serverOption)
+	}
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		fmt.Fprintln(stderr, "yttm-server:", err)
+		return 1
+	}
+	var grpcListener net.Listener
+	if *grpcAddr != "" {
+		if grpcListener, err = net.Listen("tcp", *grpcAddr); err != nil {
+			listener.Close()
+			fmt.Fprintln(stderr, "yttm-server:", err)
+			return 1
+		}
+	}
+	err = serve(ctx, listener, grpcListener, handler, *drainDelay, *shutdownTimeout)
+	if err != nil {
+		fmt.Fprintln(stderr, "yttm-server:", err)
+		return 1
+	}
+	return 0
+}
+
+// loadModels reads the models given as [name=]path together with their recorded settings
+func loadModels(paths []string) (map[string]bpe.ModelFile, error) {
+	if len(paths) == 0 {
+		return nil, errors.New("at least one --model is required")
+	}
+	models := make(map[string]bpe.ModelFile, len(paths))
+	for _, path := range paths {
+		var name string
+		if i := strings.IndexByte(path, '='); i >= 0 {
+			name, path = path[:i], path[i+1:]
+		} else {
+			name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		}
+		if _, ok := models[name]; ok {
+			return nil, fmt.Errorf("model %q is given twice", name)
+		}
+		model, err := bpe.ReadModelFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		models[name] = model
+	}
+	return models, nil
+}
+
+// serve handles the connections of the listeners until ctx is done, then drains the handler for
+// drainDelay and shuts the servers down gracefully. grpcListener may be nil.
+func serve(ctx context.Context, listener, grpcListener net.Listener, handler *server.Server,
+	drainDelay, shutdownTimeout time.Duration) error {
+	httpServer := &http.Server{Handler: handler}
+	httpErr := make(chan error, 1)
+	go func() {
+		httpErr <- httpServer.Serve(listener)
+	}()
+	var grpcServer *grpc.Server
+	grpcErr := make(chan error, 1)
+	if grpcListener != nil {
+		grpcServer = grpc.NewServer()
+		handler.RegisterTokenizerServer(grpcServer)
+		go func() {
+			grpcErr <- grpcServer.Serve(grpcListener)
+		}()
+	}
+	select {
+	case err := <-httpErr:
+		if grpcServer != nil {
+			grpcServer.Stop()
+		}
+		return err
+	case err := <-grpcErr:
+		httpServer.Close()
+		return err
+	case <-ctx.Done():
+	}
+	handler.Drain()
+	// The requests are still served while the load balancers notice the failing /readyz
+	time.Sleep(drainDelay)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if grpcServer != nil {
+		// GracefulStop waits for the running streams forever, so they are cut at the timeout
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		defer func() {
+			select {
+			case <-stopped:
+			case <-shutdownCtx.Done():
+				grpcServer.Stop()
+			}
+		}()
+	}
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-httpErr; err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
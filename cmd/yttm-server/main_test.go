@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	bpe "github.com/src-d/go-YouTokenToMe"
+	"github.com/src-d/go-YouTokenToMe/server"
+	"github.com/src-d/go-YouTokenToMe/tokenizerpb"
+)
+
+func writeModel(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "yttm-server")
+	require.NoError(t, err)
+	model, err := bpe.Train(strings.NewReader("aaab aab\nab"), 10, bpe.DefaultTrainOptions())
+	require.NoError(t, err)
+	path := filepath.Join(dir, "model.yttm")
+	file, err := os.Create(path)
+	require.NoError(t, err)
+	_, err = model.WriteTo(file)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+	return path, func() { os.RemoveAll(dir) }
+}
+
+func TestLoadModels(t *testing.T) {
+	req := require.New(t)
+	path, cleanup := writeModel(t)
+	defer cleanup()
+
+	models, err := loadModels([]string{path, "other=" + path})
+	req.NoError(err)
+	req.Len(models, 2)
+	req.Contains(models, "model")
+	req.Contains(models, "other")
+	req.Nil(models["model"].Normalization)
+
+	req.NoError(bpe.WriteNormalization(path, bpe.Normalization{bpe.Lowercase}))
+	models, err = loadModels([]string{path})
+	req.NoError(err)
+	req.Equal(bpe.Normalization{bpe.Lowercase}, models["model"].Normalization)
+
+	_, err = loadModels(nil)
+	req.Error(err)
+	_, err = loadModels([]string{path, "model=" + path})
+	req.Error(err)
+	_, err = loadModels([]string{path + ".missing"})
+	req.Error(err)
+}
+
+func TestServe(t *testing.T) {
+	req := require.New(t)
+	path, cleanup := writeModel(t)
+	defer cleanup()
+	req.NoError(bpe.WriteNormalization(path, bpe.Normalization{bpe.Lowercase}))
+	models, err := loadModels([]string{path})
+	req.NoError(err)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	req.NoError(err)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- serve(ctx, listener, nil, server.NewWithModelFiles(models), time.Second,
+			time.Minute)
+	}()
+	url := "http://" + listener.Addr().String()
+	resp, err := http.Post(url+"/encode", "application/json",
+		strings.NewReader(`{"sentences": ["AAB ab aaab"]}`))
+	req.NoError(err)
+	var body bytes.Buffer
+	_, err = body.ReadFrom(resp.Body)
+	req.NoError(err)
+	req.NoError(resp.Body.Close())
+	req.Equal(http.StatusOK, resp.StatusCode)
+	req.JSONEq(`{"ids": [[9, 5, 6, 8, 9, 8]], "subwords": null}`, body.String())
+
+	// The load balancers see that the server is draining before it stops
+	cancel()
+	resp, err = http.Get(url + "/readyz")
+	for err == nil && resp.StatusCode == http.StatusOK {
+		req.NoError(resp.Body.Close())
+		resp, err = http.Get(url + "/readyz")
+	}
+	req.NoError(err)
+	req.NoError(resp.Body.Close())
+	req.Equal(http.StatusServiceUnavailable, resp.StatusCode)
+	req.NoError(<-done)
+	_, err = http.Get(url + "/healthz")
+	req.Error(err)
+}
+
+func TestServe_GRPC(t *testing.T) {
+	req := require.New(t)
+	path, cleanup := writeModel(t)
+	defer cleanup()
+	models, err := loadModels([]string{path})
+	req.NoError(err)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	req.NoError(err)
+	grpcListener, err := net.Listen("tcp", "127.0.0.1:0")
+	req.NoError(err)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- serve(ctx, listener, grpcListener, server.NewWithModelFiles(models), 0,
+			time.Minute)
+	}()
+	conn, err := grpc.Dial(grpcListener.Addr().String(), grpc.WithInsecure())
+	req.NoError(err)
+	defer conn.Close()
+	response, err := tokenizerpb.NewTokenizerClient(conn).Encode(context.Background(),
+		&tokenizerpb.EncodeRequest{Sentences: []string{"aab ab aaab"}})
+	req.NoError(err)
+	req.Equal([]uint32{9, 5, 6, 8, 9, 8}, response.Encodings[0].Ids)
+
+	cancel()
+	req.NoError(<-done)
+	_, err = net.Dial("tcp", grpcListener.Addr().String())
+	req.Error(err)
+}
+
+func TestRun(t *testing.T) {
+	req := require.New(t)
+	var stderr bytes.Buffer
+	req.Equal(0, run(context.Background(), []string{"--help"}, &stderr))
+	req.Equal(2, run(context.Background(), []string{"--unknown"}, &stderr))
+	stderr.Reset()
+	req.Equal(1, run(context.Background(), nil, &stderr))
+	req.Contains(stderr.String(), "--model is required")
+
+	path, cleanup := writeModel(t)
+	defer cleanup()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req.Equal(0, run(ctx, []string{"--addr", "127.0.0.1:0", "--model", path}, &stderr))
+	req.Equal(0, run(ctx, []string{"--addr", "127.0.0.1:0", "--grpc_addr", "127.0.0.1:0",
+		"--model", path}, &stderr))
+	req.Equal(0, run(ctx, []string{"--addr", "127.0.0.1:0", "--model_dir", filepath.Dir(path)},
+		&stderr))
+	stderr.Reset()
+	req.Equal(2, run(ctx, []string{"--model", path, "--model_dir", filepath.Dir(path)}, &stderr))
+	req.Contains(stderr.String(), "mutually exclusive")
+	req.Equal(1, run(ctx, []string{"--model_dir", path + ".missing"}, &stderr))
+}
@@ -0,0 +1,341 @@
+// Command yttm encodes and decodes text with YouTokenToMe BPE models. Its subcommands and flags
+// follow the ones of the Python yttm tool:
+//
+//	yttm encode --model model.yttm [--output_type id|subword] [--bos] [--eos] [--reverse]
+//	            [--stream] [--n_threads N] [--dropout_prob P] [--normalize STEPS]
+//	            [--pre_tokenizer whitespace|punctuation|code] < text.txt
+//	yttm decode --model model.yttm < ids.txt
+//	yttm vocab --model model.yttm
+//	yttm index --model model.yttm --output model.idx
+//	yttm validate --model model.yttm
+//
+// The index written by the index command can be passed to --model of the other commands,
+// it is memory-mapped instead of being parsed. The text is normalized with the steps recorded
+// by bpe.WriteNormalization next to the model and split with the pre-tokenizer recorded by
+// bpe.WritePreTokenizer, if any.
+// The input is read from stdin and the output is written to stdout.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	bpe "github.com/src-d/go-YouTokenToMe"
+)
+
+const usage = `usage: yttm <command> [flags]
+
+Commands:
+  encode    encode text from stdin into token ids or subwords
+  decode    decode token ids from stdin into text
+  vocab     print the vocabulary of the model
+  index     convert the model into the memory-mapped index
+  validate  check the model for inconsistencies
+
+Run "yttm <command> --help" to see the flags of the command.
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes the command line and returns the exit code.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	var command func([]string, io.Reader, io.Writer, io.Writer) error
+	switch args[0] {
+	case "encode":
+		command = encode
+	case "decode":
+		command = decode
+	case "vocab":
+		command = vocab
+	case "index":
+		command = index
+	case "validate":
+		command = validate
+	case "-h", "--help", "help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n%s", args[0], usage)
+		return 2
+	}
+	if err := command(args[1:], stdin, stdout, stderr); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		fmt.Fprintln(stderr, "yttm:", err)
+		return 1
+	}
+	return 0
+}
+
+func newFlagSet(name string, stderr io.Writer) (*flag.FlagSet, *string) {
+	flags := flag.NewFlagSet("yttm "+name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	modelPath := flags.String("model", "", "path to the BPE model")
+	return flags, modelPath
+}
+
+func loadModel(modelPath string) (*bpe.Model, error) {
+	if modelPath == "" {
+		return nil, errors.New("--model is required")
+	}
+	// The mapping lives until the process exits
+	mapped, err := bpe.OpenMappedModel(modelPath)
+	if err == nil {
+		return mapped.Model, nil
+	}
+	if !errors.Is(err, bpe.ErrInvalidIndex) {
+		return nil, err
+	}
+	file, err := os.Open(modelPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return bpe.ReadModel(bufio.NewReader(file))
+}
+
+func encode(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags, modelPath := newFlagSet("encode", stderr)
+	outputType := flags.String("output_type", "id", "output type: id or subword")
+	bos := flags.Bool("bos", false, "add BOS token to every sentence")
+	eos := flags.Bool("eos", false, "add EOS token to every sentence")
+	reverse := flags.Bool("reverse", false, "reverse the output sequences")
+	stream := flags.Bool("stream", false, "process the input line by line")
+	nThreads := flags.Int("n_threads", -1, "number of threads, -1 means all the available")
+	dropoutProb := flags.Float64("dropout_prob", 0, "BPE-dropout probability")
+	normalize := flags.String("normalize", "", "normalization steps separated with commas, "+
+		"e.g. nfkc,lowercase; the ones recorded next to the model are used by default")
+	preTokenizerName := flags.String("pre_tokenizer", "", "how to split the text into "+
+		"words: whitespace, punctuation or code; the one recorded next to the model is used "+
+		"by default")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *outputType != "id" && *outputType != "subword" {
+		return fmt.Errorf("unknown output type %q", *outputType)
+	}
+	var preTokenizer bpe.PreTokenizer
+	var err error
+	if *preTokenizerName != "" {
+		preTokenizer, err = bpe.ParsePreTokenizer(*preTokenizerName)
+	} else {
+		preTokenizer, err = bpe.ReadPreTokenizer(*modelPath)
+	}
+	if err != nil {
+		return err
+	}
+	model, err := loadModel(*modelPath)
+	if err != nil {
+		return err
+	}
+	var normalization bpe.Normalization
+	if *normalize != "" {
+		normalization, err = bpe.ParseNormalization(*normalize)
+	} else {
+		normalization, err = bpe.ReadNormalization(*modelPath)
+	}
+	if err != nil {
+		return err
+	}
+	options := []bpe.EncodingOption{bpe.WithPreTokenizer(preTokenizer)}
+	if len(normalization) > 0 {
+		options = append(options, bpe.WithNormalizer(normalization))
+	}
+	if *bos {
+		options = append(options, bpe.WithBOS())
+	}
+	if *eos {
+		options = append(options, bpe.WithEOS())
+	}
+	if *reverse {
+		options = append(options, bpe.Reversed())
+	}
+	if *dropoutProb != 0 {
+		options = append(options, bpe.WithDropout(*dropoutProb, nil))
+	}
+	encodingConfig := bpe.NewEncodingConfig(options...)
+
+	writer := bufio.NewWriter(stdout)
+	writeLine := func(encodedSentence bpe.EncodedString) error {
+		tokens := make([]string, len(encodedSentence))
+		for i, id := range encodedSentence {
+			if *outputType == "id" {
+				tokens[i] = strconv.FormatUint(uint64(id), 10)
+				continue
+			}
+			token, err := model.IDToToken(id, false)
+			if err != nil {
+				return err
+			}
+			tokens[i] = token
+		}
+		_, err := fmt.Fprintln(writer, strings.Join(tokens, " "))
+		return err
+	}
+	if *stream {
+		encoder := model.NewStreamEncoder(stdin, encodingConfig)
+		for encoder.Next() {
+			if err := writeLine(encoder.Encoded()); err != nil {
+				return err
+			}
+			// Every line is delivered as soon as it is encoded
+			if err := writer.Flush(); err != nil {
+				return err
+			}
+		}
+		return encoder.Err()
+	}
+	encodedSentences, err := model.EncodeStreamParallel(context.Background(), stdin,
+		encodingConfig, *nThreads)
+	if err != nil {
+		return err
+	}
+	for _, encodedSentence := range encodedSentences {
+		if err := writeLine(encodedSentence); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+func decode(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags, modelPath := newFlagSet("decode", stderr)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	model, err := loadModel(*modelPath)
+	if err != nil {
+		return err
+	}
+	sentences, err := model.DecodeFromStream(stdin, bpe.NewDecodingConfig())
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(stdout)
+	for _, sentence := range sentences {
+		if _, err := fmt.Fprintln(writer, sentence); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+func vocab(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags, modelPath := newFlagSet("vocab", stderr)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	model, err := loadModel(*modelPath)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(stdout)
+	ids := model.VocabIDs()
+	for i, token := range model.Vocab() {
+		if _, err := fmt.Fprintf(writer, "%d\t%s\n", ids[i], token); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+func index(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags, modelPath := newFlagSet("index", stderr)
+	outputPath := flags.String("output", "", "path to the index to write")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *outputPath == "" {
+		return errors.New("--output is required")
+	}
+	model, err := loadModel(*modelPath)
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(*outputPath)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(file)
+	if _, err = model.WriteIndex(writer); err == nil {
+		err = writer.Flush()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	// The index is encoded the same way as the model
+	normalization, err := bpe.ReadNormalization(*modelPath)
+	if err != nil {
+		return err
+	}
+	if normalization != nil {
+		if err := bpe.WriteNormalization(*outputPath, normalization); err != nil {
+			return err
+		}
+	}
+	preTokenizer, err := bpe.ReadPreTokenizer(*modelPath)
+	if err != nil || preTokenizer == nil {
+		return err
+	}
+	return bpe.WritePreTokenizer(*outputPath, preTokenizer)
+}
+
+func validate(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags, modelPath := newFlagSet("validate", stderr)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *modelPath == "" {
+		return errors.New("--model is required")
+	}
+	var problems []bpe.Problem
+	mapped, err := bpe.OpenMappedModel(*modelPath)
+	if err == nil {
+		problems = mapped.Validate()
+	} else if errors.Is(err, bpe.ErrInvalidIndex) {
+		file, err := os.Open(*modelPath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = bpe.ReadModelStrict(bufio.NewReader(file))
+		var invalidErr *bpe.InvalidModelError
+		if errors.As(err, &invalidErr) {
+			problems = invalidErr.Problems
+		} else if err != nil {
+			return err
+		}
+	} else {
+		return err
+	}
+	writer := bufio.NewWriter(stdout)
+	for _, problem := range problems {
+		if _, err := fmt.Fprintln(writer, problem); err != nil {
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%d problems found", len(problems))
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	bpe "github.com/src-d/go-YouTokenToMe"
+)
+
+func writeModel(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "yttm")
+	require.NoError(t, err)
+	model, err := bpe.Train(strings.NewReader("aaab aab\nab"), 10, bpe.DefaultTrainOptions())
+	require.NoError(t, err)
+	path := filepath.Join(dir, "model.yttm")
+	file, err := os.Create(path)
+	require.NoError(t, err)
+	_, err = model.WriteTo(file)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+	return path, func() { os.RemoveAll(dir) }
+}
+
+func runCommand(stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestEncode(t *testing.T) {
+	req := require.New(t)
+	path, cleanup := writeModel(t)
+	defer cleanup()
+
+	code, stdout, _ := runCommand("aab ab aaab\n\nab", "encode", "--model", path)
+	req.Equal(0, code)
+	req.Equal("9 5 6 8 9 8\n\n6 8\n", stdout)
+
+	code, stdout, _ = runCommand("aab ab aaab\nab", "encode", "--model", path, "--stream",
+		"--bos", "--eos", "--reverse")
+	req.Equal(0, code)
+	req.Equal("3 8 9 8 6 5 9 2\n3 8 6 2\n", stdout)
+
+	code, stdout, _ = runCommand("aab ac", "encode", "--model", path, "--output_type",
+		"subword", "--eos")
+	req.Equal(0, code)
+	req.Equal("▁aa b ▁ a <UNK> <EOS>\n", stdout)
+
+	code, _, stderr := runCommand("aab", "encode", "--model", path, "--output_type", "other")
+	req.Equal(1, code)
+	req.Contains(stderr, "unknown output type")
+
+	code, _, stderr = runCommand("aab", "encode")
+	req.Equal(1, code)
+	req.Contains(stderr, "--model is required")
+
+	code, _, _ = runCommand("aab", "encode", "--model", path+".missing")
+	req.Equal(1, code)
+}
+
+// failingWriter fails every write like a full disk or a closed pipe
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("no space left on device")
+}
+
+func TestEncode_WriteError(t *testing.T) {
+	req := require.New(t)
+	path, cleanup := writeModel(t)
+	defer cleanup()
+
+	// The output is buffered, so the error comes from the final flush
+	var stderr bytes.Buffer
+	code := run([]string{"encode", "--model", path}, strings.NewReader("aab ab"),
+		failingWriter{}, &stderr)
+	req.Equal(1, code)
+	req.Contains(stderr.String(), "no space left on device")
+	code = run([]string{"encode", "--model", path, "--stream"}, strings.NewReader("aab ab"),
+		failingWriter{}, &stderr)
+	req.Equal(1, code)
+}
+
+func TestEncode_PreTokenizer(t *testing.T) {
+	req := require.New(t)
+	path, cleanup := writeModel(t)
+	defer cleanup()
+
+	code, stdout, _ := runCommand("aab,ab", "encode", "--model", path, "--output_type",
+		"subword", "--pre_tokenizer", "punctuation")
+	req.Equal(0, code)
+	req.Equal("▁aa b <UNK> ab\n", stdout)
+	code, _, stderr := runCommand("aab", "encode", "--model", path, "--pre_tokenizer", "words")
+	req.Equal(1, code)
+	req.Contains(stderr, "unknown pre-tokenizer")
+
+	// The recorded pre-tokenizer is used by default
+	req.NoError(ioutil.WriteFile(path+".pretok", []byte("words\n"), 0666))
+	code, _, stderr = runCommand("aab,ab", "encode", "--model", path)
+	req.Equal(1, code)
+	req.Contains(stderr, "unknown pre-tokenizer")
+	code, stdout, _ = runCommand("aab,ab", "encode", "--model", path, "--pre_tokenizer",
+		"whitespace")
+	req.Equal(0, code)
+	req.Equal("9 5 1 8\n", stdout)
+
+	req.NoError(bpe.WritePreTokenizer(path, bpe.PunctuationPreTokenizer{}))
+	code, stdout, _ = runCommand("aab,ab", "encode", "--model", path)
+	req.Equal(0, code)
+	req.Equal("9 5 1 8\n", stdout)
+	indexPath := path + ".idx"
+	code, _, _ = runCommand("", "index", "--model", path, "--output", indexPath)
+	req.Equal(0, code)
+	preTokenizer, err := bpe.ReadPreTokenizer(indexPath)
+	req.NoError(err)
+	req.Equal(bpe.PunctuationPreTokenizer{}, preTokenizer)
+}
+
+func TestEncode_Normalization(t *testing.T) {
+	req := require.New(t)
+	path, cleanup := writeModel(t)
+	defer cleanup()
+
+	code, stdout, _ := runCommand("AAB Ａb", "encode", "--model", path, "--normalize",
+		"nfkc,lowercase")
+	req.Equal(0, code)
+	req.Equal("9 5 6 8\n", stdout)
+	code, _, stderr := runCommand("AAB", "encode", "--model", path, "--normalize", "upper")
+	req.Equal(1, code)
+	req.Contains(stderr, "unknown normalization step")
+
+	req.NoError(bpe.WriteNormalization(path, bpe.Normalization{bpe.Lowercase}))
+	code, stdout, _ = runCommand("AAB", "encode", "--model", path)
+	req.Equal(0, code)
+	req.Equal("9 5\n", stdout)
+
+	indexPath := path + ".idx"
+	code, _, _ = runCommand("", "index", "--model", path, "--output", indexPath)
+	req.Equal(0, code)
+	code, stdout, _ = runCommand("AAB", "encode", "--model", indexPath)
+	req.Equal(0, code)
+	req.Equal("9 5\n", stdout)
+}
+
+func TestDecode(t *testing.T) {
+	req := require.New(t)
+	path, cleanup := writeModel(t)
+	defer cleanup()
+
+	code, stdout, _ := runCommand("9 5 6 8 9 8\n2 9 8 6 8 3", "decode", "--model", path)
+	req.Equal(0, code)
+	req.Equal("aab ab aaab\n<BOS>aaab ab<EOS>\n", stdout)
+
+	code, _, stderr := runCommand("9 5 6 x", "decode", "--model", path)
+	req.Equal(1, code)
+	req.Contains(stderr, "line 1")
+}
+
+func TestVocab(t *testing.T) {
+	req := require.New(t)
+	path, cleanup := writeModel(t)
+	defer cleanup()
+
+	code, stdout, _ := runCommand("", "vocab", "--model", path)
+	req.Equal(0, code)
+	req.Equal("0\t<PAD>\n1\t<UNK>\n2\t<BOS>\n3\t<EOS>\n4\ta\n5\tb\n6\t▁\n7\taa\n8\tab\n9\t▁aa\n",
+		stdout)
+}
+
+func TestIndex(t *testing.T) {
+	req := require.New(t)
+	path, cleanup := writeModel(t)
+	defer cleanup()
+
+	code, _, stderr := runCommand("", "index", "--model", path)
+	req.Equal(1, code)
+	req.Contains(stderr, "--output is required")
+
+	indexPath := path + ".idx"
+	code, _, _ = runCommand("", "index", "--model", path, "--output", indexPath)
+	req.Equal(0, code)
+	code, stdout, _ := runCommand("aab ab aaab", "encode", "--model", indexPath)
+	req.Equal(0, code)
+	req.Equal("9 5 6 8 9 8\n", stdout)
+	code, stdout, _ = runCommand("9 5 6 8 9 8", "decode", "--model", indexPath)
+	req.Equal(0, code)
+	req.Equal("aab ab aaab\n", stdout)
+}
+
+func TestValidate(t *testing.T) {
+	req := require.New(t)
+	path, cleanup := writeModel(t)
+	defer cleanup()
+
+	code, stdout, _ := runCommand("", "validate", "--model", path)
+	req.Equal(0, code)
+	req.Empty(stdout)
+	indexPath := path + ".idx"
+	code, _, _ = runCommand("", "index", "--model", path, "--output", indexPath)
+	req.Equal(0, code)
+	code, stdout, _ = runCommand("", "validate", "--model", indexPath)
+	req.Equal(0, code)
+	req.Empty(stdout)
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	req.NoError(err)
+	_, err = file.Write([]byte{1, 2})
+	req.NoError(err)
+	req.NoError(file.Close())
+	code, stdout, stderr := runCommand("", "validate", "--model", path)
+	req.Equal(1, code)
+	req.Equal("byte 84: 2 bytes of trailing garbage\n", stdout)
+	req.Contains(stderr, "1 problems found")
+}
+
+func TestRun(t *testing.T) {
+	req := require.New(t)
+	code, _, stderr := runCommand("")
+	req.Equal(2, code)
+	req.Contains(stderr, "usage")
+
+	code, _, stderr = runCommand("", "train")
+	req.Equal(2, code)
+	req.Contains(stderr, "unknown command")
+
+	code, stdout, _ := runCommand("", "--help")
+	req.Equal(0, code)
+	req.Contains(stdout, "usage")
+
+	code, _, _ = runCommand("", "vocab", "--help")
+	req.Equal(0, code)
+}
@@ -0,0 +1,134 @@
+package bpe
+
+import "sync"
+
+type mergeEvent struct {
+	priority int
+	pos      int
+}
+
+// mergeQueue is a binary min-heap of merge events ordered by the priority and then by
+// the position. It stores the events by value so that pushing does not allocate once
+// the underlying array is large enough.
+type mergeQueue []mergeEvent
+
+func (mq mergeQueue) less(i, j int) bool {
+	return mq[i].priority < mq[j].priority ||
+		mq[i].priority == mq[j].priority && mq[i].pos < mq[j].pos
+}
+
+func (mq *mergeQueue) push(event mergeEvent) {
+	*mq = append(*mq, event)
+	queue := *mq
+	for i := len(queue) - 1; i > 0; {
+		parent := (i - 1) / 2
+		if !queue.less(i, parent) {
+			break
+		}
+		queue[i], queue[parent] = queue[parent], queue[i]
+		i = parent
+	}
+}
+
+func (mq *mergeQueue) pop() mergeEvent {
+	queue := *mq
+	top := queue[0]
+	last := len(queue) - 1
+	queue[0] = queue[last]
+	queue = queue[:last]
+	for i := 0; ; {
+		smallest := i
+		if left := 2*i + 1; left < last && queue.less(left, smallest) {
+			smallest = left
+		}
+		if right := 2*i + 2; right < last && queue.less(right, smallest) {
+			smallest = right
+		}
+		if smallest == i {
+			break
+		}
+		queue[i], queue[smallest] = queue[smallest], queue[i]
+		i = smallest
+	}
+	*mq = queue
+	return top
+}
+
+// encodingBuffers is the scratch space of the encoding which is reused from word to word
+// and from sentence to sentence.
+type encodingBuffers struct {
+	word    []encodingToken
+	queue   mergeQueue
+	dropped []mergeEvent
+	// ids and offsets collect the encoding of a word which is stored in the word cache
+	ids     []TokenID
+	offsets []Offset
+}
+
+// maxPooledWordLength limits the size of the buffers which are returned to the pool, so that
+// a single huge word does not pin its memory forever.
+const maxPooledWordLength = 1 << 12
+
+var encodingBuffersPool = sync.Pool{
+	New: func() interface{} {
+		return &encodingBuffers{}
+	},
+}
+
+func getEncodingBuffers() *encodingBuffers {
+	return encodingBuffersPool.Get().(*encodingBuffers)
+}
+
+func putEncodingBuffers(buffers *encodingBuffers) {
+	if cap(buffers.word) > maxPooledWordLength {
+		return
+	}
+	encodingBuffersPool.Put(buffers)
+}
+
+// pushIfRuleExists checks whether the token at leftPos of the word and the next one can be
+// merged and if so adds the merge suggestion to the priority queue.
+func (b *encodingBuffers) pushIfRuleExists(rule2id pairTable, word []encodingToken, leftPos int) {
+	rightPos := word[leftPos].next
+	if priority, ok := rule2id.get(newTokenIDPair(word[leftPos].id, word[rightPos].id)); ok {
+		b.queue.push(mergeEvent{priority, leftPos})
+	}
+}
+
+// Encoder encodes sentences with the given configuration the same way EncodeSentence does but
+// owns its scratch buffers, so that encoding into a large enough destination slice does not
+// allocate once the buffers have grown. Encoder is not safe for concurrent use; create one per
+// goroutine instead.
+//
+//	encoder := model.NewEncoder(encodingConfig)
+//	var ids bpe.EncodedString
+//	for _, sentence := range sentences {
+//		ids, err = encoder.AppendEncode(ids[:0], sentence)
+//		...
+//	}
+type Encoder struct {
+	model          Model
+	encodingConfig EncodingConfig
+	buffers        encodingBuffers
+}
+
+// NewEncoder creates Encoder which encodes sentences with the given encodingConfig
+func (m Model) NewEncoder(encodingConfig EncodingConfig) *Encoder {
+	return &Encoder{
+		model:          m,
+		encodingConfig: encodingConfig,
+	}
+}
+
+// AppendEncode encodes the sentence and appends its ids to dst. It returns the extended slice,
+// or dst unchanged together with the error. When reversing is enabled, only the appended ids are
+// reversed.
+func (e *Encoder) AppendEncode(dst EncodedString, sentence string) (EncodedString, error) {
+	dst, _, err := e.model.appendEncoded(&e.buffers, dst, nil, sentence, e.encodingConfig, false)
+	return dst, err
+}
+
+// Encode encodes the sentence into a new slice
+func (e *Encoder) Encode(sentence string) (EncodedString, error) {
+	return e.AppendEncode(nil, sentence)
+}
@@ -0,0 +1,100 @@
+package bpe
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeQueue(t *testing.T) {
+	req := require.New(t)
+	random := rand.New(rand.NewSource(3))
+	var queue mergeQueue
+	var expected []mergeEvent
+	for i := 0; i < 500; i++ {
+		event := mergeEvent{random.Intn(20), random.Intn(50)}
+		queue.push(event)
+		expected = append(expected, event)
+	}
+	sort.Slice(expected, func(i, j int) bool {
+		return expected[i].priority < expected[j].priority ||
+			expected[i].priority == expected[j].priority && expected[i].pos < expected[j].pos
+	})
+	for _, event := range expected {
+		req.Equal(event, queue.pop())
+	}
+	req.Empty(queue)
+}
+
+func TestEncoder_AppendEncode(t *testing.T) {
+	req := require.New(t)
+	sentences := randomSentences(200)
+	for _, encodingConfig := range []EncodingConfig{
+		NewEncodingConfig(),
+		NewEncodingConfig(WithBOS(), WithEOS(), Reversed()),
+	} {
+		encoder := BPE.NewEncoder(encodingConfig)
+		var all EncodedString
+		var expectedAll EncodedString
+		for _, sentence := range sentences {
+			expected, err := BPE.EncodeSentence(sentence, encodingConfig)
+			req.NoError(err)
+			ids, err := encoder.Encode(sentence)
+			req.NoError(err)
+			req.Equal(expected, ids)
+			all, err = encoder.AppendEncode(all, sentence)
+			req.NoError(err)
+			expectedAll = append(expectedAll, expected...)
+		}
+		req.Equal(expectedAll, all)
+	}
+
+	ids, err := BPE.NewEncoder(NewEncodingConfig()).AppendEncode(EncodedString{42}, " \t")
+	req.NoError(err)
+	req.Equal(EncodedString{42}, ids)
+
+	model := BPE
+	model.specialTokens.eos = -1
+	dst := EncodedString{42}
+	ids, err = model.NewEncoder(NewEncodingConfig(WithEOS())).AppendEncode(dst, "abc")
+	req.True(errors.Is(err, ErrSpecialTokenMissing))
+	req.Equal(EncodedString{42}, ids)
+	ids, err = BPE.NewEncoder(EncodingConfig{dropout: 2}).AppendEncode(dst, "abc")
+	req.Equal(ErrInvalidDropout, err)
+	req.Equal(EncodedString{42}, ids)
+}
+
+func TestEncoder_AppendEncodeDoesNotAllocate(t *testing.T) {
+	req := require.New(t)
+	model, _, lines := getBenchmarkModel(t)
+	encoder := model.NewEncoder(NewEncodingConfig(WithBOS(), WithEOS()))
+	ids := make(EncodedString, 0, 1<<16)
+	// Let the scratch buffers grow to the longest word first
+	for _, line := range lines[:1000] {
+		_, err := encoder.AppendEncode(ids[:0], line)
+		req.NoError(err)
+	}
+	i := 0
+	allocs := testing.AllocsPerRun(1000, func() {
+		ids, _ = encoder.AppendEncode(ids[:0], lines[i%1000])
+		i++
+	})
+	req.Zero(allocs)
+}
+
+func BenchmarkEncoder_AppendEncode(b *testing.B) {
+	model, _, lines := getBenchmarkModel(b)
+	encoder := model.NewEncoder(NewEncodingConfig())
+	var ids EncodedString
+	var err error
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if ids, err = encoder.AppendEncode(ids[:0], lines[i%len(lines)]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package bpe
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+var (
+	// ErrUnknownTokenID is matched by errors.Is for UnknownTokenIDError
+	ErrUnknownTokenID = errors.New("token id is impossible")
+	// ErrTruncatedModel is matched by errors.Is for TruncatedModelError
+	ErrTruncatedModel = errors.New("model dump is truncated")
+	// ErrSpecialTokenMissing is matched by errors.Is for SpecialTokenMissingError
+	ErrSpecialTokenMissing = errors.New("model was trained without the special token")
+	// ErrInvalidDropout is returned when the dropout probability is not in [0, 1]
+	ErrInvalidDropout = errors.New("dropout probability must be in [0, 1]")
+	// ErrLineTooLong is returned when a line of the stream exceeds the maximal line length
+	ErrLineTooLong = errors.New("line is too long")
+	// ErrInvalidModel is matched by errors.Is for InvalidModelError
+	ErrInvalidModel = errors.New("model is inconsistent")
+	// ErrInvalidIndex is returned when the model index file is malformed
+	ErrInvalidIndex = errors.New("model index is invalid")
+	// ErrUnknownNormalization is returned by ParseNormalization for unknown steps
+	ErrUnknownNormalization = errors.New("unknown normalization step")
+	// ErrUnknownPreTokenizer is returned by ParsePreTokenizer for unknown names and by
+	// WritePreTokenizer for the pre-tokenizers which are not built in
+	ErrUnknownPreTokenizer = errors.New("unknown pre-tokenizer")
+	// ErrInvalidWordBounds is returned when PreTokenizer returns an empty word, a word out of
+	// the sentence or a word before the requested position
+	ErrInvalidWordBounds = errors.New("pre-tokenizer returned impossible word bounds")
+)
+
+// UnknownTokenIDError is returned when a token id is neither in the vocabulary of the model
+// nor among the ids of its special tokens
+type UnknownTokenIDError struct {
+	ID TokenID
+}
+
+func (e *UnknownTokenIDError) Error() string {
+	return fmt.Sprintf("%d: %v", e.ID, ErrUnknownTokenID)
+}
+
+// Is makes UnknownTokenIDError match ErrUnknownTokenID
+func (e *UnknownTokenIDError) Is(target error) bool {
+	return target == ErrUnknownTokenID
+}
+
+// TruncatedModelError is returned when the binary dump of the model ends prematurely
+type TruncatedModelError struct {
+	// Offset is the number of bytes which were read before the dump ended
+	Offset int64
+}
+
+func (e *TruncatedModelError) Error() string {
+	return fmt.Sprintf("%v at byte %d", ErrTruncatedModel, e.Offset)
+}
+
+// Is makes TruncatedModelError match ErrTruncatedModel
+func (e *TruncatedModelError) Is(target error) bool {
+	return target == ErrTruncatedModel
+}
+
+// InvalidModelError is returned by ReadModelStrict when the model has problems
+type InvalidModelError struct {
+	Problems []Problem
+}
+
+func (e *InvalidModelError) Error() string {
+	descriptions := make([]string, len(e.Problems))
+	for i, problem := range e.Problems {
+		descriptions[i] = problem.String()
+	}
+	return fmt.Sprintf("%v: %s", ErrInvalidModel, strings.Join(descriptions, "; "))
+}
+
+// Is makes InvalidModelError match ErrInvalidModel
+func (e *InvalidModelError) Is(target error) bool {
+	return target == ErrInvalidModel
+}
+
+// SpecialTokenMissingError is returned when encoding requires a special token which the model
+// was trained without
+type SpecialTokenMissingError struct {
+	// Kind is the name of the token: "<BOS>", "<EOS>", "<PAD>" or "<UNK>"
+	Kind string
+}
+
+func (e *SpecialTokenMissingError) Error() string {
+	return fmt.Sprintf("%v %s", ErrSpecialTokenMissing, e.Kind)
+}
+
+// Is makes SpecialTokenMissingError match ErrSpecialTokenMissing
+func (e *SpecialTokenMissingError) Is(target error) bool {
+	return target == ErrSpecialTokenMissing
+}
+
+// LineError is an error which has occurred while processing a line of a stream
+type LineError struct {
+	// Line is the number of the line, starting from 1
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// Logger logs the errors which occur in the package
+type Logger interface {
+	Errorf(format string, args ...interface{})
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Errorf(string, ...interface{}) {}
+
+// atomicLogger forwards to the logger which was set last. It lets SetLogger be called while
+// the package is in use.
+type atomicLogger struct {
+	// value is loggerBox, since atomic.Value requires the same concrete type on every store
+	value atomic.Value
+}
+
+type loggerBox struct {
+	Logger
+}
+
+func (al *atomicLogger) Errorf(format string, args ...interface{}) {
+	al.value.Load().(loggerBox).Errorf(format, args...)
+}
+
+var logger = newAtomicLogger()
+
+func newAtomicLogger() *atomicLogger {
+	al := &atomicLogger{}
+	al.value.Store(loggerBox{nopLogger{}})
+	return al
+}
+
+// SetLogger makes the package log its errors to the given logger, e.g. logrus.StandardLogger().
+// Nothing is logged by default and after SetLogger(nil). It is safe to call at any time.
+func SetLogger(l Logger) {
+	if l == nil {
+		l = nopLogger{}
+	}
+	logger.value.Store(loggerBox{l})
+}
@@ -0,0 +1,99 @@
+package bpe
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type recordingLogger struct {
+	messages []string
+}
+
+func (rl *recordingLogger) Errorf(format string, args ...interface{}) {
+	rl.messages = append(rl.messages, fmt.Sprintf(format, args...))
+}
+
+func TestErrors(t *testing.T) {
+	req := require.New(t)
+	_, err := BPE.IDToToken(30, false)
+	req.True(errors.Is(err, ErrUnknownTokenID))
+	var unknownErr *UnknownTokenIDError
+	req.True(errors.As(err, &unknownErr))
+	req.Equal(TokenID(30), unknownErr.ID)
+	req.Equal("30: token id is impossible", err.Error())
+
+	_, err = BPE.DecodeSentence(EncodedString{9, 25}, NewDecodingConfig())
+	req.True(errors.As(err, &unknownErr))
+	req.Equal(TokenID(25), unknownErr.ID)
+
+	model := BPE
+	model.specialTokens.bos = -1
+	_, err = model.EncodeSentence("ab", NewEncodingConfig(WithBOS()))
+	req.True(errors.Is(err, ErrSpecialTokenMissing))
+	var missingErr *SpecialTokenMissingError
+	req.True(errors.As(err, &missingErr))
+	req.Equal(bosToken, missingErr.Kind)
+	req.False(errors.Is(err, ErrUnknownTokenID))
+
+	_, err = BPE.EncodeSentence("ab", NewEncodingConfig(WithDropout(-1, nil)))
+	req.Equal(ErrInvalidDropout, err)
+
+	var buf bytes.Buffer
+	_, err = BPE.WriteTo(&buf)
+	req.NoError(err)
+	_, err = ReadModel(bytes.NewReader(buf.Bytes()[:50]))
+	req.True(errors.Is(err, ErrTruncatedModel))
+	var truncatedErr *TruncatedModelError
+	req.True(errors.As(err, &truncatedErr))
+	req.Equal(int64(50), truncatedErr.Offset)
+	_, err = ReadModel(bytes.NewReader(nil))
+	req.True(errors.As(err, &truncatedErr))
+	req.Equal(int64(0), truncatedErr.Offset)
+
+	corrupted := append([]byte{}, buf.Bytes()...)
+	corrupted[51] = 20
+	_, err = ReadModel(bytes.NewReader(corrupted))
+	req.True(errors.As(err, &unknownErr))
+	req.Equal(TokenID(20), unknownErr.ID)
+
+	err = &LineError{3, ErrLineTooLong}
+	req.True(errors.Is(err, ErrLineTooLong))
+	err = &LineError{3, &UnknownTokenIDError{7}}
+	req.True(errors.Is(err, ErrUnknownTokenID))
+	req.Equal("line 3: 7: token id is impossible", err.Error())
+}
+
+func TestSetLogger(t *testing.T) {
+	req := require.New(t)
+	defer SetLogger(nil)
+	recorder := &recordingLogger{}
+	SetLogger(recorder)
+	_, err := BPE.IDToToken(30, false)
+	req.Error(err)
+	req.Equal([]string{"30: token id is impossible"}, recorder.messages)
+
+	SetLogger(nil)
+	_, err = BPE.IDToToken(30, false)
+	req.Error(err)
+	req.Len(recorder.messages, 1)
+}
+
+func TestSetLogger_Concurrent(t *testing.T) {
+	// Run with -race: the logger may be replaced while the package logs
+	defer SetLogger(nil)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			_, _ = BPE.IDToToken(30, false)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		SetLogger(&recordingLogger{})
+	}
+	<-done
+}
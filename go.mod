@@ -1,8 +1,11 @@
 module github.com/src-d/go-YouTokenToMe
 
-go 1.12
+go 1.13
 
 require (
-	github.com/sirupsen/logrus v1.4.2
-	github.com/stretchr/testify v1.4.0
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/stretchr/testify v1.5.1
+	golang.org/x/text v0.3.6
+	google.golang.org/grpc v1.38.0
+	google.golang.org/protobuf v1.26.0
 )
@@ -0,0 +1,326 @@
+package bpe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"unsafe"
+)
+
+// The index is a derived form of the model which keeps the lookup tables exactly as they are laid
+// out in memory, so that they can be used right from the memory-mapped file without any parsing.
+// All the numbers are little-endian and every section is aligned to 8 bytes:
+//
+//	header      indexHeaderSize bytes: magic, version, section sizes, specials, space id
+//	chars       nChars × (char int32, id uint32)
+//	rules       nRules × (left, right, result uint32)
+//	pair keys   nSlots × uint64
+//	pair values nSlots × int32
+//	tokens      nTokens × (char int32, start, end uint32)
+//	text index  nTexts × uint32
+//	merged text nMerged bytes
+const (
+	indexMagic      = "YTTMIDX\x01"
+	indexVersion    = 1
+	indexHeaderSize = 64
+)
+
+var littleEndianHost = func() bool {
+	x := uint16(1)
+	return *(*byte)(unsafe.Pointer(&x)) == 1
+}()
+
+type indexHeader struct {
+	nChars   uint32
+	nRules   uint32
+	nSlots   uint32
+	nPairs   uint32
+	nTokens  uint32
+	nTexts   uint32
+	nMerged  uint32
+	specials specialTokens
+	spaceID  TokenID
+}
+
+// sections returns the sizes of the sections which follow the header, in their order
+func (h indexHeader) sections() [7]int {
+	return [7]int{
+		8 * int(h.nChars),
+		12 * int(h.nRules),
+		8 * int(h.nSlots),
+		4 * int(h.nSlots),
+		12 * int(h.nTokens),
+		4 * int(h.nTexts),
+		int(h.nMerged),
+	}
+}
+
+func alignIndex(size int) int {
+	return (size + 7) &^ 7
+}
+
+// sortedTextIndex returns the ids of the chars and the merged tokens sorted by their texts.
+// Several merged tokens may have the same text, only the one which TokenToID returns is kept.
+func (m Model) sortedTextIndex() []TokenID {
+	if m.revRecipe == nil {
+		return m.textIndex
+	}
+	ids := make([]TokenID, 0, len(m.revRecipe))
+	for text, id := range m.revRecipe {
+		switch text {
+		case unkToken, padToken, bosToken, eosToken:
+			continue
+		}
+		ids = append(ids, id)
+	}
+	var left, right []byte
+	sort.Slice(ids, func(i, j int) bool {
+		left, right = m.appendToken(left[:0], ids[i]), m.appendToken(right[:0], ids[j])
+		return bytes.Compare(left, right) < 0
+	})
+	return ids
+}
+
+// WriteIndex writes the index of the model which is loaded by OpenMappedModel. The index is
+// larger than the binary dump written by WriteTo but it can be used without parsing.
+// It returns the number of written bytes.
+func (m Model) WriteIndex(writer io.Writer) (int64, error) {
+	texts := m.sortedTextIndex()
+	header := indexHeader{
+		nChars:   uint32(len(m.char2id)),
+		nRules:   uint32(len(m.rules)),
+		nSlots:   uint32(len(m.rule2id.keys)),
+		nPairs:   uint32(m.rule2id.size),
+		nTokens:  uint32(len(m.tokens)),
+		nTexts:   uint32(len(texts)),
+		nMerged:  uint32(len(m.mergedText)),
+		specials: m.specialTokens,
+		spaceID:  m.spaceID,
+	}
+	buf := make([]byte, indexHeaderSize)
+	copy(buf, indexMagic)
+	le := binary.LittleEndian
+	for i, value := range []uint32{indexVersion, header.nChars, header.nRules, header.nSlots,
+		header.nPairs, header.nTokens, header.nTexts, header.nMerged,
+		uint32(header.specials.unk), uint32(header.specials.pad), uint32(header.specials.bos),
+		uint32(header.specials.eos), uint32(header.spaceID)} {
+		le.PutUint32(buf[8+4*i:], value)
+	}
+	buf = padIndex(buf)
+	for id, token := range m.tokens {
+		if token.char != -1 {
+			buf = appendUint32(buf, uint32(token.char))
+			buf = appendUint32(buf, uint32(id))
+		}
+	}
+	buf = padIndex(buf)
+	for _, r := range m.rules {
+		buf = appendUint32(appendUint32(appendUint32(buf, uint32(r.left)), uint32(r.right)),
+			uint32(r.result))
+	}
+	buf = padIndex(buf)
+	for _, key := range m.rule2id.keys {
+		buf = appendUint32(appendUint32(buf, uint32(key)), uint32(key>>32))
+	}
+	for _, value := range m.rule2id.values {
+		buf = appendUint32(buf, uint32(value))
+	}
+	buf = padIndex(buf)
+	for _, token := range m.tokens {
+		buf = appendUint32(appendUint32(appendUint32(buf, uint32(token.char)), token.start),
+			token.end)
+	}
+	buf = padIndex(buf)
+	for _, id := range texts {
+		buf = appendUint32(buf, uint32(id))
+	}
+	buf = padIndex(append(padIndex(buf), m.mergedText...))
+	n, err := writer.Write(buf)
+	if err != nil {
+		logger.Errorf("Failed to write the index: %v", err)
+	}
+	return int64(n), err
+}
+
+func appendUint32(buf []byte, value uint32) []byte {
+	return append(buf, byte(value), byte(value>>8), byte(value>>16), byte(value>>24))
+}
+
+func padIndex(buf []byte) []byte {
+	for len(buf)%8 != 0 {
+		buf = append(buf, 0)
+	}
+	return buf
+}
+
+func readIndexHeader(data []byte) (indexHeader, error) {
+	var header indexHeader
+	if len(data) < indexHeaderSize || string(data[:len(indexMagic)]) != indexMagic {
+		return header, fmt.Errorf("not an index file: %w", ErrInvalidIndex)
+	}
+	le := binary.LittleEndian
+	if version := le.Uint32(data[8:]); version != indexVersion {
+		return header, fmt.Errorf("unsupported version %d: %w", version, ErrInvalidIndex)
+	}
+	values := make([]uint32, 12)
+	for i := range values {
+		values[i] = le.Uint32(data[12+4*i:])
+	}
+	header = indexHeader{
+		nChars:  values[0],
+		nRules:  values[1],
+		nSlots:  values[2],
+		nPairs:  values[3],
+		nTokens: values[4],
+		nTexts:  values[5],
+		nMerged: values[6],
+		specials: specialTokens{int32(values[7]), int32(values[8]), int32(values[9]),
+			int32(values[10])},
+		spaceID: TokenID(values[11]),
+	}
+	if header.nSlots != 0 && (header.nSlots < 8 || header.nSlots&(header.nSlots-1) != 0 ||
+		2*uint64(header.nPairs) > uint64(header.nSlots)) {
+		return header, fmt.Errorf("%d slots of the pair table are impossible: %w",
+			header.nSlots, ErrInvalidIndex)
+	}
+	size := int64(indexHeaderSize)
+	for _, section := range header.sections() {
+		size += int64(alignIndex(section))
+	}
+	if size != int64(len(data)) {
+		return header, fmt.Errorf("size is %d bytes, expected %d: %w", len(data), size,
+			ErrInvalidIndex)
+	}
+	return header, nil
+}
+
+// modelFromIndex creates the model from the index. If alias is set, the tables of the model
+// point right into data, which must stay valid and unchanged while the model is in use, and be
+// aligned to 8 bytes. Otherwise the tables are decoded into the newly allocated memory.
+func modelFromIndex(data []byte, alias bool) (*Model, error) {
+	header, err := readIndexHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	alias = alias && littleEndianHost
+	var sections [7][]byte
+	offset := indexHeaderSize
+	for i, size := range header.sections() {
+		sections[i] = data[offset : offset+size : offset+size]
+		offset += alignIndex(size)
+	}
+	le := binary.LittleEndian
+	model := &Model{
+		char2id:       make(map[rune]TokenID, header.nChars),
+		specialTokens: header.specials,
+		spaceID:       header.spaceID,
+	}
+	chars := sections[0]
+	for i := 0; i < len(chars); i += 8 {
+		model.char2id[rune(le.Uint32(chars[i:]))] = TokenID(le.Uint32(chars[i+4:]))
+	}
+	if alias {
+		aliasSlice(unsafe.Pointer(&model.rules), sections[1], int(header.nRules))
+		aliasSlice(unsafe.Pointer(&model.rule2id.keys), sections[2], int(header.nSlots))
+		aliasSlice(unsafe.Pointer(&model.rule2id.values), sections[3], int(header.nSlots))
+		aliasSlice(unsafe.Pointer(&model.tokens), sections[4], int(header.nTokens))
+		aliasSlice(unsafe.Pointer(&model.textIndex), sections[5], int(header.nTexts))
+		model.mergedText = sections[6]
+	} else {
+		model.rules = make([]rule, header.nRules)
+		for i := range model.rules {
+			section := sections[1][12*i:]
+			model.rules[i] = rule{TokenID(le.Uint32(section)), TokenID(le.Uint32(section[4:])),
+				TokenID(le.Uint32(section[8:]))}
+		}
+		model.rule2id.keys = make([]TokenIDPair, header.nSlots)
+		model.rule2id.values = make([]int32, header.nSlots)
+		for i := range model.rule2id.keys {
+			model.rule2id.keys[i] = TokenIDPair(le.Uint64(sections[2][8*i:]))
+			model.rule2id.values[i] = int32(le.Uint32(sections[3][4*i:]))
+		}
+		model.tokens = make([]tokenEntry, header.nTokens)
+		for i := range model.tokens {
+			section := sections[4][12*i:]
+			model.tokens[i] = tokenEntry{rune(le.Uint32(section)), le.Uint32(section[4:]),
+				le.Uint32(section[8:])}
+		}
+		model.textIndex = make([]TokenID, header.nTexts)
+		for i := range model.textIndex {
+			model.textIndex[i] = TokenID(le.Uint32(sections[5][4*i:]))
+		}
+		model.mergedText = append([]byte(nil), sections[6]...)
+	}
+	model.rule2id.size = int(header.nPairs)
+	model.rule2id.shift = 64
+	for nSlots := header.nSlots; nSlots > 1; nSlots >>= 1 {
+		model.rule2id.shift--
+	}
+	if err := checkIndexTables(model); err != nil {
+		return nil, err
+	}
+	return model, nil
+}
+
+// checkIndexTables makes sure that the tables of the model read from the index refer only
+// to the existing entries, so that a corrupted index cannot make the model index out of range
+// or probe the pair table forever
+func checkIndexTables(model *Model) error {
+	nTokens, nRules := int64(len(model.tokens)), int32(len(model.rules))
+	for char, id := range model.char2id {
+		if int64(id) >= nTokens {
+			return fmt.Errorf("char %q has token id %d out of range: %w", char, id,
+				ErrInvalidIndex)
+		}
+	}
+	for i, r := range model.rules {
+		if int64(r.left) >= nTokens || int64(r.right) >= nTokens || int64(r.result) >= nTokens {
+			return fmt.Errorf("rule %d has token ids out of range: %w", i, ErrInvalidIndex)
+		}
+	}
+	nPairs := 0
+	for i, value := range model.rule2id.values {
+		if value < -1 || value >= nRules {
+			return fmt.Errorf("pair slot %d has rule %d out of range: %w", i, value,
+				ErrInvalidIndex)
+		}
+		if value != -1 {
+			nPairs++
+		}
+	}
+	if nPairs != model.rule2id.size {
+		return fmt.Errorf("pair table has %d pairs, expected %d: %w", nPairs,
+			model.rule2id.size, ErrInvalidIndex)
+	}
+	if nPairs > 0 && nPairs == len(model.rule2id.values) {
+		return fmt.Errorf("pair table has no empty slots: %w", ErrInvalidIndex)
+	}
+	nMerged := uint32(len(model.mergedText))
+	for id, token := range model.tokens {
+		if token.start > token.end || token.end > nMerged {
+			return fmt.Errorf("token %d has text bounds [%d, %d) out of range: %w", id,
+				token.start, token.end, ErrInvalidIndex)
+		}
+	}
+	for i, id := range model.textIndex {
+		if int64(id) >= nTokens {
+			return fmt.Errorf("text index entry %d has token id %d out of range: %w", i, id,
+				ErrInvalidIndex)
+		}
+	}
+	return nil
+}
+
+// aliasSlice makes the slice which slicePtr points to use the first n elements stored in data
+func aliasSlice(slicePtr unsafe.Pointer, data []byte, n int) {
+	if n == 0 {
+		return
+	}
+	header := (*reflect.SliceHeader)(slicePtr)
+	header.Data = uintptr(unsafe.Pointer(&data[0]))
+	header.Len = n
+	header.Cap = n
+}
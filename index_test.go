@@ -0,0 +1,124 @@
+package bpe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeIndex(t testing.TB, model Model) []byte {
+	var buf bytes.Buffer
+	n, err := model.WriteIndex(&buf)
+	require.NoError(t, err)
+	require.Equal(t, int64(buf.Len()), n)
+	return buf.Bytes()
+}
+
+func TestModel_SortedTextIndex(t *testing.T) {
+	req := require.New(t)
+	req.Equal([]TokenID{4, 9, 12, 10, 11, 8, 14, 13, 7, 6, 5}, BPE.sortedTextIndex())
+}
+
+func TestModelFromIndex(t *testing.T) {
+	req := require.New(t)
+	data := writeIndex(t, BPE)
+	req.Zero(len(data) % 8)
+	for _, alias := range []bool{false, true} {
+		model, err := modelFromIndex(data, alias)
+		req.NoError(err)
+		req.Equal(BPE.char2id, model.char2id)
+		req.Equal(BPE.rules, model.rules)
+		req.Equal(BPE.rule2id, model.rule2id)
+		req.Equal(BPE.tokens, model.tokens)
+		req.Equal(BPE.mergedText, model.mergedText)
+		req.Equal(BPE.specialTokens, model.specialTokens)
+		req.Equal(BPE.spaceID, model.spaceID)
+		req.Nil(model.revRecipe)
+
+		for token, expected := range BPE.revRecipe {
+			id, ok := model.TokenToID(token)
+			req.True(ok, token)
+			req.Equal(expected, id, token)
+		}
+		_, ok := model.TokenToID("abc")
+		req.False(ok)
+		_, ok = model.TokenToID("")
+		req.False(ok)
+		req.Equal(BPE.Vocab(), model.Vocab())
+
+		var expected, actual bytes.Buffer
+		_, err = BPE.WriteTo(&expected)
+		req.NoError(err)
+		_, err = model.WriteTo(&actual)
+		req.NoError(err)
+		req.Equal(expected.Bytes(), actual.Bytes())
+		// The index of the index is the same
+		req.Equal(data, writeIndex(t, *model))
+	}
+
+	empty := newModel(0)
+	model, err := modelFromIndex(writeIndex(t, *empty), true)
+	req.NoError(err)
+	expected, err := empty.EncodeSentence("abc", NewEncodingConfig())
+	req.NoError(err)
+	ids, err := model.EncodeSentence("abc", NewEncodingConfig())
+	req.NoError(err)
+	req.Equal(expected, ids)
+}
+
+func TestModelFromIndex_Invalid(t *testing.T) {
+	req := require.New(t)
+	data := writeIndex(t, BPE)
+	for _, broken := range [][]byte{
+		nil,
+		data[:indexHeaderSize-1],
+		data[:len(data)-8],
+		append(append([]byte{}, data...), 0, 0, 0, 0, 0, 0, 0, 0),
+		append([]byte("YTTMIDX\x02"), data[8:]...),
+		append(append(append([]byte{}, data[:8]...), 2, 0, 0, 0), data[12:]...),
+		// 5 slots of the pair table
+		append(append(append([]byte{}, data[:20]...), 5, 0, 0, 0), data[24:]...),
+	} {
+		_, err := modelFromIndex(broken, false)
+		req.True(errors.Is(err, ErrInvalidIndex), "%v", err)
+	}
+
+	header, err := readIndexHeader(data)
+	req.NoError(err)
+	var offsets [7]int
+	offset := indexHeaderSize
+	for i, size := range header.sections() {
+		offsets[i] = offset
+		offset += alignIndex(size)
+	}
+	// corrupt sets the 32-bit numbers starting at the given byte of the section
+	corrupt := func(section, start int, values ...uint32) []byte {
+		broken := append([]byte{}, data...)
+		for i, value := range values {
+			binary.LittleEndian.PutUint32(broken[offsets[section]+start+4*i:], value)
+		}
+		return broken
+	}
+	fullPairTable := make([]uint32, header.nSlots)
+	nTokens, nMerged := header.nTokens, header.nMerged
+	for name, broken := range map[string][]byte{
+		"char id":            corrupt(0, 4, nTokens),
+		"rule left":          corrupt(1, 0, nTokens),
+		"rule result":        corrupt(1, 8, 1<<31),
+		"pair value":         corrupt(3, 0, header.nRules),
+		"negative pair":      corrupt(3, 0, uint32(0xfffffffe)),
+		"no empty slots":     corrupt(3, 0, fullPairTable...),
+		"token end":          corrupt(4, 8, nMerged+1),
+		"token start > end":  corrupt(4, 4, 1, 0),
+		"text index id":      corrupt(5, 0, nTokens),
+		"last text index id": corrupt(5, 4*int(header.nTexts-1), ^uint32(0)),
+	} {
+		for _, alias := range []bool{false, true} {
+			_, err := modelFromIndex(broken, alias)
+			req.True(errors.Is(err, ErrInvalidIndex), "%s: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package bpe
+
+// MappedModel is Model whose lookup tables are backed by a memory-mapped index file written by
+// WriteIndex. Opening it does not parse the tables, only checks them in a single pass, and
+// the processes which map the same file share its pages. The model must not be used after Close.
+//
+// The file must not be modified or truncated while the model is open: the changes may become
+// visible to the model and a truncation crashes the process. A new version of the index must be
+// written to another file which is renamed over the old one.
+type MappedModel struct {
+	*Model
+	data  []byte
+	unmap func([]byte) error
+}
+
+// OpenMappedModel maps the index file into memory and creates the model on top of it. All
+// the sections of the index are checked to refer only to the existing entries. On the platforms
+// without mmap support and on big-endian hosts the file is read into memory instead. A malformed
+// index is reported with an error which matches ErrInvalidIndex.
+//
+// The embedded *Model and the copies of its value refer to the mapped memory, so they must not
+// outlive Close: using them afterwards crashes the process instead of returning an error.
+func OpenMappedModel(path string) (*MappedModel, error) {
+	data, unmap, err := mapFile(path)
+	if err != nil {
+		logger.Errorf("Failed to map %s: %v", path, err)
+		return nil, err
+	}
+	model, err := modelFromIndex(data, unmap != nil)
+	if err != nil {
+		logger.Errorf("Failed to load the index %s: %v", path, err)
+		if unmap != nil {
+			_ = unmap(data)
+		}
+		return nil, err
+	}
+	if unmap != nil && !littleEndianHost {
+		// The tables have been decoded, the mapping is not needed anymore
+		if err := unmap(data); err != nil {
+			return nil, err
+		}
+		unmap = nil
+	}
+	return &MappedModel{model, data, unmap}, nil
+}
+
+// Close releases the mapping of the index file. The embedded *Model is set to nil, so that
+// the later use of the model panics.
+func (mm *MappedModel) Close() error {
+	mm.Model = nil
+	if mm.unmap == nil {
+		return nil
+	}
+	err := mm.unmap(mm.data)
+	mm.data, mm.unmap = nil, nil
+	return err
+}
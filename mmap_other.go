@@ -0,0 +1,13 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package bpe
+
+import "io/ioutil"
+
+// mapFile reads the whole file into memory since mmap is not supported on this platform.
+// unmap is always nil.
+func mapFile(path string) (data []byte, unmap func([]byte) error, err error) {
+	data, err = ioutil.ReadFile(path)
+	return data, nil, err
+}
@@ -0,0 +1,90 @@
+package bpe
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeIndexFile(t testing.TB, model Model) (string, func()) {
+	dir, err := ioutil.TempDir("", "yttm")
+	require.NoError(t, err)
+	path := filepath.Join(dir, "model.idx")
+	require.NoError(t, ioutil.WriteFile(path, writeIndex(t, model), 0644))
+	return path, func() { os.RemoveAll(dir) }
+}
+
+func TestOpenMappedModel(t *testing.T) {
+	req := require.New(t)
+	model, _, lines := getBenchmarkModel(t)
+	path, cleanup := writeIndexFile(t, *model)
+	defer cleanup()
+	mapped, err := OpenMappedModel(path)
+	req.NoError(err)
+	encodingConfig := NewEncodingConfig(WithBOS(), WithEOS())
+	for _, line := range lines[:1000] {
+		expected, err := model.EncodeSentence(line, encodingConfig)
+		req.NoError(err)
+		ids, err := mapped.EncodeSentence(line, encodingConfig)
+		req.NoError(err)
+		req.Equal(expected, ids)
+		sentence, err := mapped.DecodeSentence(ids, NewDecodingConfig(SkipSpecialTokens()))
+		req.NoError(err)
+		expectedSentence, err := model.DecodeSentence(ids, NewDecodingConfig(SkipSpecialTokens()))
+		req.NoError(err)
+		req.Equal(expectedSentence, sentence)
+	}
+	req.Equal(model.Vocab(), mapped.Vocab())
+	for _, token := range model.Vocab() {
+		expected, _ := model.TokenToID(token)
+		id, ok := mapped.TokenToID(token)
+		req.True(ok)
+		req.Equal(expected, id)
+	}
+	req.NoError(mapped.Close())
+	req.NoError(mapped.Close())
+	req.Nil(mapped.Model)
+	req.Panics(func() {
+		_, _ = mapped.EncodeSentence("ab", encodingConfig)
+	})
+}
+
+func TestOpenMappedModel_Errors(t *testing.T) {
+	req := require.New(t)
+	_, err := OpenMappedModel(filepath.Join(os.TempDir(), "does-not-exist.idx"))
+	req.True(os.IsNotExist(err))
+
+	dir, err := ioutil.TempDir("", "yttm")
+	req.NoError(err)
+	defer os.RemoveAll(dir)
+	for name, content := range map[string][]byte{
+		"empty.idx":  nil,
+		"broken.idx": writeIndex(t, BPE)[:100],
+	} {
+		path := filepath.Join(dir, name)
+		req.NoError(ioutil.WriteFile(path, content, 0644))
+		_, err = OpenMappedModel(path)
+		req.True(errors.Is(err, ErrInvalidIndex), "%v", err)
+	}
+}
+
+func BenchmarkOpenMappedModel(b *testing.B) {
+	model, _, _ := getBenchmarkModel(b)
+	path, cleanup := writeIndexFile(b, *model)
+	defer cleanup()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		mapped, err := OpenMappedModel(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err = mapped.Close(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package bpe
+
+import (
+	"os"
+	"syscall"
+)
+
+// mapFile maps the whole file into memory for reading. The mapping is private, but the file
+// still must not change while it is mapped, see MappedModel. unmap releases the mapping; it is
+// nil if the file has been read into memory instead.
+func mapFile(path string) (data []byte, unmap func([]byte) error, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	if info.Size() == 0 {
+		// Empty files cannot be mapped
+		return []byte{}, nil, nil
+	}
+	data, err = syscall.Mmap(int(file.Fd()), 0, int(info.Size()), syscall.PROT_READ,
+		syscall.MAP_PRIVATE)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, syscall.Munmap, nil
+}
@@ -0,0 +1,108 @@
+package bpe
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+// ModelFile is a model read from a file together with the settings recorded next to the file,
+// which must be applied to encode the sentences the same way as the model was trained
+type ModelFile struct {
+	Model *Model
+	// Normalization is recorded with WriteNormalization, it is nil if there is no record
+	Normalization Normalization
+	// PreTokenizer is recorded with WritePreTokenizer, it is nil if there is no record
+	PreTokenizer PreTokenizer
+}
+
+// ReadModelFile reads the binary dump of the model from the file with ReadModelStrict together
+// with the settings recorded next to it
+func ReadModelFile(path string) (ModelFile, error) {
+	contents, err := readModelFileContents(path)
+	if err != nil {
+		return ModelFile{}, err
+	}
+	return contents.parse()
+}
+
+// EncodingOptions returns the options which apply the recorded settings, they go before
+// the other options of the encoding
+func (mf ModelFile) EncodingOptions() []EncodingOption {
+	var options []EncodingOption
+	if len(mf.Normalization) > 0 {
+		options = append(options, WithNormalizer(mf.Normalization))
+	}
+	if mf.PreTokenizer != nil {
+		options = append(options, WithPreTokenizer(mf.PreTokenizer))
+	}
+	return options
+}
+
+// modelFileContents is the raw contents of a model file and of the records next to it
+type modelFileContents struct {
+	model []byte
+	// normalization and preTokenizer are nil if there are no records
+	normalization []byte
+	preTokenizer  []byte
+}
+
+func readModelFileContents(path string) (modelFileContents, error) {
+	var contents modelFileContents
+	var err error
+	if contents.model, err = ioutil.ReadFile(path); err != nil {
+		return contents, err
+	}
+	if contents.normalization, err = readRecord(path + normalizationSuffix); err != nil {
+		return contents, err
+	}
+	if contents.preTokenizer, err = readRecord(path + preTokenizerSuffix); err != nil {
+		return contents, err
+	}
+	return contents, nil
+}
+
+// readRecord reads the file of a setting recorded next to a model, it returns nil if there is
+// no such file
+func readRecord(path string) ([]byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// version returns the hash of all the contents
+func (c modelFileContents) version() fileVersion {
+	hash := sha256.New()
+	for _, part := range [][]byte{c.model, c.normalization, c.preTokenizer} {
+		var size [8]byte
+		binary.LittleEndian.PutUint64(size[:], uint64(len(part)))
+		hash.Write(size[:])
+		hash.Write(part)
+	}
+	var version fileVersion
+	hash.Sum(version[:0])
+	return version
+}
+
+func (c modelFileContents) parse() (ModelFile, error) {
+	model, err := ReadModelStrict(bytes.NewReader(c.model))
+	if err != nil {
+		return ModelFile{}, err
+	}
+	normalization, err := ParseNormalization(string(c.normalization))
+	if err != nil {
+		return ModelFile{}, fmt.Errorf("%s: %w", normalizationSuffix, err)
+	}
+	var preTokenizer PreTokenizer
+	if c.preTokenizer != nil {
+		if preTokenizer, err = ParsePreTokenizer(string(c.preTokenizer)); err != nil {
+			return ModelFile{}, fmt.Errorf("%s: %w", preTokenizerSuffix, err)
+		}
+	}
+	return ModelFile{model, normalization, preTokenizer}, nil
+}
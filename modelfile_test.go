@@ -0,0 +1,70 @@
+package bpe
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadModelFile(t *testing.T) {
+	req := require.New(t)
+	dir, err := ioutil.TempDir("", "modelfile")
+	req.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ab.yttm")
+
+	_, err = ReadModelFile(path)
+	req.True(os.IsNotExist(err))
+
+	req.NoError(ioutil.WriteFile(path, modelBytes(t, "aaab aab\nab", 10), 0666))
+	file, err := ReadModelFile(path)
+	req.NoError(err)
+	req.Equal(10, file.Model.VocabSize())
+	req.Nil(file.Normalization)
+	req.Nil(file.PreTokenizer)
+	req.Empty(file.EncodingOptions())
+
+	req.NoError(WriteNormalization(path, Normalization{Lowercase}))
+	file, err = ReadModelFile(path)
+	req.NoError(err)
+	req.Equal(Normalization{Lowercase}, file.Normalization)
+	ids, err := file.Model.EncodeSentence("AB", NewEncodingConfig(file.EncodingOptions()...))
+	req.NoError(err)
+	req.Equal(EncodedString{6, 8}, ids)
+
+	req.NoError(WritePreTokenizer(path, PunctuationPreTokenizer{}))
+	file, err = ReadModelFile(path)
+	req.NoError(err)
+	req.Equal(PunctuationPreTokenizer{}, file.PreTokenizer)
+	ids, err = file.Model.EncodeSentence("AB!", NewEncodingConfig(file.EncodingOptions()...))
+	req.NoError(err)
+	req.Equal(EncodedString{6, 8, 1}, ids)
+
+	req.NoError(ioutil.WriteFile(path+preTokenizerSuffix, []byte("words\n"), 0666))
+	_, err = ReadModelFile(path)
+	req.True(errors.Is(err, ErrUnknownPreTokenizer))
+
+	req.NoError(ioutil.WriteFile(path+normalizationSuffix, []byte("upper\n"), 0666))
+	_, err = ReadModelFile(path)
+	req.True(errors.Is(err, ErrUnknownNormalization))
+
+	req.NoError(ioutil.WriteFile(path, []byte{1, 2, 3}, 0666))
+	_, err = ReadModelFile(path)
+	req.True(errors.Is(err, ErrTruncatedModel))
+}
+
+func TestModelFileContents_Version(t *testing.T) {
+	req := require.New(t)
+	version := modelFileContents{model: []byte("ab")}.version()
+	req.Equal(version, modelFileContents{model: []byte("ab"), normalization: []byte{}}.version())
+	req.NotEqual(version, modelFileContents{model: []byte("a"),
+		normalization: []byte("b")}.version())
+	req.NotEqual(version, modelFileContents{model: []byte("ab"),
+		normalization: []byte("lowercase\n")}.version())
+	req.NotEqual(version, modelFileContents{model: []byte("ab"),
+		preTokenizer: []byte("code\n")}.version())
+}
@@ -0,0 +1,142 @@
+package bpe
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalizer transforms the text before it is split into words, so that the different spellings
+// of the same text get the same tokens. The same normalizer must be used for training and
+// encoding; Normalization can be recorded next to the model for that.
+type Normalizer interface {
+	Normalize(text string) string
+}
+
+// NormalizationStep is a built-in step of Normalization
+type NormalizationStep string
+
+const (
+	// NFC composes the chars with their combining marks, so that "é" written as "e" followed by
+	// U+0301 becomes the single char U+00E9
+	NFC NormalizationStep = "nfc"
+	// NFKC additionally replaces the compatibility chars with their plain equivalents, e.g.
+	// full-width letters and digits, ligatures and no-break space
+	NFKC NormalizationStep = "nfkc"
+	// Lowercase maps all the letters to lower case
+	Lowercase NormalizationStep = "lowercase"
+	// StripControl removes the control chars except the white space ones
+	StripControl NormalizationStep = "strip_control"
+	// CollapseWhitespace replaces every run of white space with a single space and trims
+	// the text
+	CollapseWhitespace NormalizationStep = "collapse_whitespace"
+)
+
+// normalizationSuffix is appended to the path of the model to get the path of the recorded
+// normalization
+const normalizationSuffix = ".norm"
+
+// Normalization is Normalizer which applies the built-in steps in order. Unlike an arbitrary
+// Normalizer, it can be recorded with String and restored with ParseNormalization.
+type Normalization []NormalizationStep
+
+// ParseNormalization restores Normalization from its String, the steps are separated with commas.
+// The empty string is the normalization without steps.
+func ParseNormalization(spec string) (Normalization, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+	var normalization Normalization
+	for _, name := range strings.Split(spec, ",") {
+		step := NormalizationStep(strings.TrimSpace(name))
+		switch step {
+		case NFC, NFKC, Lowercase, StripControl, CollapseWhitespace:
+			normalization = append(normalization, step)
+		default:
+			return nil, fmt.Errorf("%q: %w", name, ErrUnknownNormalization)
+		}
+	}
+	return normalization, nil
+}
+
+// String returns the steps separated with commas
+func (n Normalization) String() string {
+	names := make([]string, len(n))
+	for i, step := range n {
+		names[i] = string(step)
+	}
+	return strings.Join(names, ",")
+}
+
+// Normalize applies the steps to the text. The text is returned as is if the steps do not
+// change it.
+func (n Normalization) Normalize(text string) string {
+	for _, step := range n {
+		switch step {
+		case NFC:
+			text = norm.NFC.String(text)
+		case NFKC:
+			text = norm.NFKC.String(text)
+		case Lowercase:
+			text = strings.ToLower(text)
+		case StripControl:
+			text = strings.Map(func(char rune) rune {
+				if unicode.IsControl(char) && !unicode.IsSpace(char) {
+					return -1
+				}
+				return char
+			}, text)
+		case CollapseWhitespace:
+			text = collapseWhitespace(text)
+		}
+	}
+	return text
+}
+
+func collapseWhitespace(text string) string {
+	// Most of the texts are already collapsed, so they are checked first to avoid the copying
+	collapsed := true
+	previousSpace := true
+	for _, char := range text {
+		space := unicode.IsSpace(char)
+		if space && (previousSpace || char != ' ') {
+			collapsed = false
+			break
+		}
+		previousSpace = space
+	}
+	if collapsed && !previousSpace {
+		return text
+	}
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// WriteNormalization records the normalization next to the model file, so that the model is
+// encoded the same way as it was trained. It is read by ReadNormalization.
+func WriteNormalization(modelPath string, normalization Normalization) error {
+	return ioutil.WriteFile(modelPath+normalizationSuffix, []byte(normalization.String()+"\n"),
+		0666)
+}
+
+// ReadNormalization reads the normalization which was recorded next to the model file by
+// WriteNormalization. The models without the record are not normalized, so nil is returned
+// for them.
+func ReadNormalization(modelPath string) (Normalization, error) {
+	data, err := readRecord(modelPath + normalizationSuffix)
+	if err != nil {
+		return nil, err
+	}
+	return ParseNormalization(string(data))
+}
+
+// WithNormalizer normalizes every sentence before it is encoded. The offsets of the tokens
+// refer to the normalized sentence then.
+func WithNormalizer(normalizer Normalizer) EncodingOption {
+	return func(encodingConfig *EncodingConfig) {
+		encodingConfig.normalizer = normalizer
+	}
+}
@@ -0,0 +1,99 @@
+package bpe
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNormalization(t *testing.T) {
+	req := require.New(t)
+	normalization, err := ParseNormalization(" nfkc, lowercase,strip_control\n")
+	req.NoError(err)
+	req.Equal(Normalization{NFKC, Lowercase, StripControl}, normalization)
+	req.Equal("nfkc,lowercase,strip_control", normalization.String())
+
+	normalization, err = ParseNormalization("")
+	req.NoError(err)
+	req.Nil(normalization)
+	req.Equal("", normalization.String())
+
+	_, err = ParseNormalization("nfc,upper")
+	req.True(errors.Is(err, ErrUnknownNormalization))
+	req.Contains(err.Error(), `"upper"`)
+}
+
+func TestNormalization_Normalize(t *testing.T) {
+	req := require.New(t)
+	decomposed := "été"
+	req.Equal("été", Normalization{NFC}.Normalize(decomposed))
+	req.Equal("ab12 ﬁ", Normalization{NFC}.Normalize("ab12 ﬁ"))
+	req.Equal("ab12 fi", Normalization{NFKC}.Normalize("ａｂ１２ ﬁ"))
+	req.Equal("ÀB", Normalization{NFC}.Normalize("ÀB"))
+	req.Equal("àb", Normalization{NFC, Lowercase}.Normalize("ÀB"))
+	req.Equal("ab\tc\n", Normalization{StripControl}.Normalize("a\x00b\tc\u007f\n"))
+	req.Equal("a b c", Normalization{CollapseWhitespace}.Normalize("  a \t b\n\nc "))
+	req.Equal("a b", Normalization{CollapseWhitespace}.Normalize("a b"))
+	req.Equal("", Normalization{CollapseWhitespace}.Normalize(" \n"))
+	req.Equal("", Normalization{CollapseWhitespace}.Normalize(""))
+	req.Equal("ÀB", Normalization(nil).Normalize("ÀB"))
+
+	text := "already normalized text"
+	req.Equal(0.0, testing.AllocsPerRun(10, func() {
+		Normalization{NFKC, Lowercase, StripControl, CollapseWhitespace}.Normalize(text)
+	}))
+}
+
+func TestReadNormalization(t *testing.T) {
+	req := require.New(t)
+	dir, err := ioutil.TempDir("", "normalization")
+	req.NoError(err)
+	defer os.RemoveAll(dir)
+	modelPath := filepath.Join(dir, "model.yttm")
+
+	normalization, err := ReadNormalization(modelPath)
+	req.NoError(err)
+	req.Nil(normalization)
+
+	req.NoError(WriteNormalization(modelPath, Normalization{NFKC, Lowercase}))
+	normalization, err = ReadNormalization(modelPath)
+	req.NoError(err)
+	req.Equal(Normalization{NFKC, Lowercase}, normalization)
+
+	req.NoError(ioutil.WriteFile(modelPath+".norm", []byte("nfd"), 0666))
+	_, err = ReadNormalization(modelPath)
+	req.True(errors.Is(err, ErrUnknownNormalization))
+}
+
+func TestWithNormalizer(t *testing.T) {
+	req := require.New(t)
+	opts := DefaultTrainOptions()
+	opts.Normalizer = Normalization{NFKC, Lowercase}
+	model, err := Train(strings.NewReader("Café CAFÉ café ｃａｆé"), 12, opts)
+	req.NoError(err)
+	// All the spellings are the same word after the normalization
+	req.Equal([]string{"<PAD>", "<UNK>", "<BOS>", "<EOS>", "a", "c", "f", "é", "▁", "ca", "fé",
+		"▁ca"}, model.Vocab())
+
+	config := NewEncodingConfig(WithNormalizer(opts.Normalizer))
+	expected, err := model.EncodeSentence("café", config)
+	req.NoError(err)
+	for _, sentence := range []string{"Café", "CAFÉ", "café", "ｃａｆé"} {
+		encoded, err := model.EncodeSentence(sentence, config)
+		req.NoError(err)
+		req.Equal(expected, encoded, sentence)
+	}
+	encoded, err := model.EncodeSentence("café", NewEncodingConfig())
+	req.NoError(err)
+	req.NotEqual(expected, encoded)
+
+	// The offsets refer to the normalized sentence
+	_, offsets, err := model.EncodeSentenceWithOffsets(" CAFÉ", config)
+	req.NoError(err)
+	req.Equal([]Offset{{1, 3}, {3, 6}}, offsets)
+}
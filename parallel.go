@@ -0,0 +1,128 @@
+package bpe
+
+import (
+	"context"
+	"io"
+	"runtime"
+	"sync"
+)
+
+type encodingJob struct {
+	index    int
+	sentence string
+}
+
+type encodingResult struct {
+	index           int
+	encodedSentence EncodedString
+	err             error
+}
+
+// encodeParallel encodes the sentences sent by produce on the given number of goroutines and
+// collects the results in the order of the sentences. produce must stop and return when ctx is
+// done. If several sentences fail, the error of the first one is returned together with
+// the encodings of the preceding sentences; wrapErr, if not nil, adds the context to it.
+func (m Model) encodeParallel(ctx context.Context, encodingConfig EncodingConfig, workers int,
+	produce func(ctx context.Context, jobs chan<- encodingJob) error,
+	wrapErr func(index int, err error) error) ([]EncodedString, error) {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	jobs := make(chan encodingJob, workers)
+	results := make(chan encodingResult, workers)
+	var produceErr error
+	go func() {
+		defer close(jobs)
+		produceErr = produce(ctx, jobs)
+	}()
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				encodedSentence, err := m.EncodeSentence(job.sentence, encodingConfig)
+				results <- encodingResult{job.index, encodedSentence, err}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var encodedSentences []EncodedString
+	errIndex := -1
+	var err error
+	for result := range results {
+		if result.err != nil {
+			if errIndex == -1 || result.index < errIndex {
+				errIndex, err = result.index, result.err
+			}
+			cancel()
+			continue
+		}
+		for len(encodedSentences) <= result.index {
+			encodedSentences = append(encodedSentences, nil)
+		}
+		encodedSentences[result.index] = result.encodedSentence
+	}
+	// All the workers have finished, so produce has returned as well
+	if errIndex != -1 {
+		if wrapErr != nil {
+			err = wrapErr(errIndex, err)
+		}
+		return encodedSentences[:errIndex], err
+	}
+	if produceErr != nil {
+		return encodedSentences, produceErr
+	}
+	return encodedSentences, nil
+}
+
+// EncodeSentencesParallel works like EncodeSentences but encodes the sentences on the given
+// number of goroutines, runtime.NumCPU() if workers is not positive. The output is the same
+// as the output of EncodeSentences unless BPE-dropout is enabled. The encoding stops when
+// ctx is done.
+func (m Model) EncodeSentencesParallel(ctx context.Context, sentences []string,
+	encodingConfig EncodingConfig, workers int) ([]EncodedString, error) {
+	encodedSentences, err := m.encodeParallel(ctx, encodingConfig, workers,
+		func(ctx context.Context, jobs chan<- encodingJob) error {
+			for i, sentence := range sentences {
+				select {
+				case jobs <- encodingJob{i, sentence}:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			return nil
+		}, nil)
+	if encodedSentences == nil && err == nil {
+		encodedSentences = []EncodedString{}
+	}
+	return encodedSentences, err
+}
+
+// EncodeStreamParallel works like EncodeStream but encodes the sentences on the given number
+// of goroutines, runtime.NumCPU() if workers is not positive. The output is the same as
+// the output of EncodeStream unless BPE-dropout is enabled. The encoding stops when ctx is done.
+// Errors which are specific to a line are reported as *LineError.
+func (m Model) EncodeStreamParallel(ctx context.Context, reader io.Reader,
+	encodingConfig EncodingConfig, workers int) ([]EncodedString, error) {
+	return m.encodeParallel(ctx, encodingConfig, workers,
+		func(ctx context.Context, jobs chan<- encodingJob) error {
+			lines := newLineReader(reader)
+			for i := 0; lines.next(); i++ {
+				select {
+				case jobs <- encodingJob{i, string(lines.line)}:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			return lines.error()
+		}, func(index int, err error) error {
+			return &LineError{index + 1, err}
+		})
+}
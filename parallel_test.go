@@ -0,0 +1,115 @@
+package bpe
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func randomSentences(n int) []string {
+	random := rand.New(rand.NewSource(7))
+	sentences := make([]string, n)
+	for i := range sentences {
+		words := make([]string, 1+random.Intn(5))
+		for j := range words {
+			word := make([]byte, 1+random.Intn(8))
+			for k := range word {
+				word[k] = "abcdx"[random.Intn(5)]
+			}
+			words[j] = string(word)
+		}
+		sentences[i] = strings.Join(words, " ")
+	}
+	return sentences
+}
+
+func TestModel_EncodeSentencesParallel(t *testing.T) {
+	req := require.New(t)
+	sentences := randomSentences(1000)
+	encodingConfig := NewEncodingConfig(WithBOS(), WithEOS(), Reversed())
+	expected, err := BPE.EncodeSentences(sentences, encodingConfig)
+	req.NoError(err)
+	for _, workers := range []int{0, 1, 3, 16} {
+		ids, err := BPE.EncodeSentencesParallel(context.Background(), sentences, encodingConfig,
+			workers)
+		req.NoError(err)
+		req.Equal(expected, ids)
+	}
+
+	ids, err := BPE.EncodeSentencesParallel(context.Background(), nil, encodingConfig, 2)
+	req.NoError(err)
+	req.Equal([]EncodedString{}, ids)
+
+	ids, err = BPE.EncodeSentencesParallel(context.Background(), []string{"", "ab", ""},
+		NewEncodingConfig(), 2)
+	req.NoError(err)
+	req.Equal([]EncodedString{nil, {9, 7}, nil}, ids)
+
+	model := BPE
+	model.specialTokens.eos = -1
+	_, err = model.EncodeSentencesParallel(context.Background(), sentences,
+		NewEncodingConfig(WithEOS()), 4)
+	req.Error(err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = BPE.EncodeSentencesParallel(ctx, sentences, encodingConfig, 4)
+	req.Equal(context.Canceled, err)
+}
+
+func TestModel_EncodeStreamParallel(t *testing.T) {
+	req := require.New(t)
+	sentences := randomSentences(1000)
+	encodingConfig := NewEncodingConfig(WithBOS())
+	expected, err := BPE.EncodeStream(strings.NewReader(strings.Join(sentences, "\n")),
+		encodingConfig)
+	req.NoError(err)
+	ids, err := BPE.EncodeStreamParallel(context.Background(),
+		strings.NewReader(strings.Join(sentences, "\n")), encodingConfig, 4)
+	req.NoError(err)
+	req.Equal(expected, ids)
+
+	ids, err = BPE.EncodeStreamParallel(context.Background(), strings.NewReader(""),
+		encodingConfig, 4)
+	req.NoError(err)
+	req.Empty(ids)
+
+	ids, err = BPE.EncodeSentencesParallel(context.Background(), sentences,
+		NewEncodingConfig(WithDropout(0.3, rand.NewSource(1))), 4)
+	req.NoError(err)
+	for i, encodedSentence := range ids {
+		restored := ""
+		for _, id := range encodedSentence {
+			token, err := BPE.IDToToken(id, true)
+			req.NoError(err)
+			restored += strings.Replace(token, "<UNK>", "x", -1)
+		}
+		expected := sentences[i]
+		for strings.Contains(expected, "xx") {
+			expected = strings.Replace(expected, "xx", "x", -1)
+		}
+		req.Equal(" "+expected, restored)
+	}
+}
+
+func TestModel_EncodeStreamParallel_Errors(t *testing.T) {
+	req := require.New(t)
+	longLine := strings.Repeat("abcd ", 100000)
+	ids, err := BPE.EncodeStreamParallel(context.Background(),
+		strings.NewReader("ab\n"+longLine), NewEncodingConfig(), 2)
+	req.NoError(err)
+	req.Len(ids, 2)
+	req.Len(ids[1], 400000)
+
+	model := BPE
+	model.specialTokens.eos = -1
+	ids, err = model.EncodeStreamParallel(context.Background(), strings.NewReader("ab\nab"),
+		NewEncodingConfig(WithEOS()), 2)
+	req.Empty(ids)
+	lineErr, ok := err.(*LineError)
+	req.True(ok)
+	req.Equal(1, lineErr.Line)
+}
@@ -0,0 +1,253 @@
+package bpe
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PreTokenizer splits the sentences into the words which are encoded independently: BPE merges
+// never cross the boundaries of the words. A word which does not follow white space or
+// the beginning of the sentence is encoded without the space token in front, so that it is glued
+// to the previous word when decoded. The same pre-tokenizer must be used for training and
+// encoding.
+type PreTokenizer interface {
+	// NextWord returns the bounds of the first word of the sentence which starts at pos or
+	// later. start is -1 if there are no more words. The words must not be empty and must not
+	// contain white space. The bounds which break these rules make the encoding and
+	// the training fail with ErrInvalidWordBounds.
+	NextWord(sentence string, pos int) (start, end int)
+}
+
+// preTokenizerSuffix is appended to the path of the model to get the path of the recorded
+// pre-tokenizer
+const preTokenizerSuffix = ".pretok"
+
+// preTokenizers are the built-in pre-tokenizers by their names
+var preTokenizers = map[string]PreTokenizer{
+	"whitespace":  WhitespacePreTokenizer{},
+	"punctuation": PunctuationPreTokenizer{},
+	"code":        CodePreTokenizer{},
+}
+
+// ParsePreTokenizer returns the built-in pre-tokenizer by its name: "whitespace", "punctuation"
+// or "code"
+func ParsePreTokenizer(name string) (PreTokenizer, error) {
+	preTokenizer, ok := preTokenizers[strings.TrimSpace(name)]
+	if !ok {
+		return nil, fmt.Errorf("%q: %w", name, ErrUnknownPreTokenizer)
+	}
+	return preTokenizer, nil
+}
+
+// PreTokenizerName returns the name of the built-in pre-tokenizer which ParsePreTokenizer
+// accepts. The second value is false for the other pre-tokenizers.
+func PreTokenizerName(preTokenizer PreTokenizer) (string, bool) {
+	switch preTokenizer.(type) {
+	case WhitespacePreTokenizer:
+		return "whitespace", true
+	case PunctuationPreTokenizer:
+		return "punctuation", true
+	case CodePreTokenizer:
+		return "code", true
+	default:
+		return "", false
+	}
+}
+
+// WritePreTokenizer records the built-in pre-tokenizer next to the model file, so that
+// the model is encoded the same way as it was trained. It is read by ReadPreTokenizer.
+// The other pre-tokenizers cannot be recorded.
+func WritePreTokenizer(modelPath string, preTokenizer PreTokenizer) error {
+	name, ok := PreTokenizerName(preTokenizer)
+	if !ok {
+		return fmt.Errorf("%T: %w", preTokenizer, ErrUnknownPreTokenizer)
+	}
+	return ioutil.WriteFile(modelPath+preTokenizerSuffix, []byte(name+"\n"), 0666)
+}
+
+// ReadPreTokenizer reads the pre-tokenizer which was recorded next to the model file by
+// WritePreTokenizer. The models without the record are split on white space, so nil is
+// returned for them.
+func ReadPreTokenizer(modelPath string) (PreTokenizer, error) {
+	data, err := readRecord(modelPath + preTokenizerSuffix)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	return ParsePreTokenizer(string(data))
+}
+
+// WhitespacePreTokenizer splits the sentences on white space the same way strings.Fields does.
+// It is used when no other PreTokenizer is set.
+type WhitespacePreTokenizer struct{}
+
+// NextWord implements PreTokenizer
+func (WhitespacePreTokenizer) NextWord(sentence string, pos int) (start, end int) {
+	return nextWord(sentence, pos)
+}
+
+// PunctuationPreTokenizer splits the sentences on white space and additionally makes every
+// punctuation or symbol char a separate word: "Hello, world!" is split into "Hello", ",",
+// "world" and "!". The words are encoded the same regardless of the adjacent punctuation then.
+type PunctuationPreTokenizer struct{}
+
+// NextWord implements PreTokenizer
+func (PunctuationPreTokenizer) NextWord(sentence string, pos int) (start, end int) {
+	start = -1
+	for pos < len(sentence) {
+		char, size := decodeRuneAt(sentence, pos)
+		switch class := classifyChar(char); {
+		case class == spaceClass:
+			if start != -1 {
+				return start, pos
+			}
+		case class == punctClass:
+			if start != -1 {
+				return start, pos
+			}
+			return pos, pos + size
+		case start == -1:
+			start = pos
+		}
+		pos += size
+	}
+	return start, len(sentence)
+}
+
+// CodePreTokenizer splits source code into identifiers, numbers and single punctuation or symbol
+// chars, and splits the identifiers further on camelCase and snake_case boundaries and between
+// letters and digits: "parseHTTPRequest(req_id2)" is split into "parse", "HTTP", "Request", "(",
+// "req", "_", "id", "2" and ")".
+type CodePreTokenizer struct{}
+
+// NextWord implements PreTokenizer
+func (CodePreTokenizer) NextWord(sentence string, pos int) (start, end int) {
+	start = -1
+	previous := spaceClass
+	for pos < len(sentence) {
+		char, size := decodeRuneAt(sentence, pos)
+		class := classifyChar(char)
+		if start == -1 {
+			switch class {
+			case spaceClass:
+			case punctClass:
+				return pos, pos + size
+			default:
+				start = pos
+			}
+		} else {
+			switch {
+			case class == spaceClass || class == punctClass:
+				return start, pos
+			case (previous == digitClass) != (class == digitClass):
+				return start, pos
+			case previous == lowerClass && class == upperClass:
+				// "parseHTTP" -> "parse", "HTTP"
+				return start, pos
+			case previous == upperClass && class == upperClass && pos+size < len(sentence):
+				// "HTTPRequest" -> "HTTP", "Request": the last upper case letter of the run
+				// starts the next word if a lower case letter follows it
+				next, _ := decodeRuneAt(sentence, pos+size)
+				if classifyChar(next) == lowerClass {
+					return start, pos
+				}
+			}
+		}
+		previous = class
+		pos += size
+	}
+	return start, len(sentence)
+}
+
+// charClass is the category of a char which decides where the words are split
+type charClass int
+
+const (
+	spaceClass charClass = iota
+	punctClass
+	digitClass
+	upperClass
+	// lowerClass includes the letters without case and the combining marks
+	lowerClass
+)
+
+func classifyChar(char rune) charClass {
+	switch {
+	case unicode.IsSpace(char):
+		return spaceClass
+	case unicode.IsUpper(char) || unicode.IsTitle(char):
+		return upperClass
+	case unicode.IsLetter(char) || unicode.IsMark(char):
+		return lowerClass
+	case unicode.IsNumber(char):
+		return digitClass
+	case unicode.IsPunct(char) || unicode.IsSymbol(char):
+		return punctClass
+	default:
+		// Control and format chars stay inside the words, like with white space splitting
+		return lowerClass
+	}
+}
+
+func decodeRuneAt(sentence string, pos int) (rune, int) {
+	if char := sentence[pos]; char < utf8.RuneSelf {
+		return rune(char), 1
+	}
+	return utf8.DecodeRuneInString(sentence[pos:])
+}
+
+// wordKey identifies a word together with whether it is attached to the previous word, since
+// the attached words are encoded without the space token. It is the key of the word counts in
+// training and of WordCache.
+type wordKey struct {
+	word     string
+	attached bool
+}
+
+// followsSpace reports whether the word which starts at pos begins the sentence or follows white
+// space, i.e. whether it is encoded with the space token in front
+func followsSpace(sentence string, pos int) bool {
+	if pos == 0 {
+		return true
+	}
+	char, _ := utf8.DecodeLastRuneInString(sentence[:pos])
+	return unicode.IsSpace(char)
+}
+
+// WithPreTokenizer sets how the sentences are split into words. WhitespacePreTokenizer is used
+// by default.
+func WithPreTokenizer(preTokenizer PreTokenizer) EncodingOption {
+	return func(encodingConfig *EncodingConfig) {
+		encodingConfig.preTokenizer = preTokenizer
+	}
+}
+
+// nextWord finds the next word with the pre-tokenizer of the configuration. The bounds of
+// the words are checked, so that a broken pre-tokenizer cannot make the callers slice out of
+// range or loop forever, and so are the words for white space.
+func (encodingConfig EncodingConfig) nextWord(sentence string, pos int) (start, end int,
+	err error) {
+	if encodingConfig.preTokenizer == nil {
+		start, end = nextWord(sentence, pos)
+		return start, end, nil
+	}
+	start, end = encodingConfig.preTokenizer.NextWord(sentence, pos)
+	if start == -1 {
+		return start, end, nil
+	}
+	if start < pos || end <= start || end > len(sentence) {
+		logger.Errorf("%T returned the word [%d, %d) at position %d", encodingConfig.preTokenizer,
+			start, end, pos)
+		return -1, -1, fmt.Errorf("[%d, %d) at position %d: %w", start, end, pos,
+			ErrInvalidWordBounds)
+	}
+	if strings.IndexFunc(sentence[start:end], unicode.IsSpace) != -1 {
+		logger.Errorf("%T returned the word [%d, %d) with white space",
+			encodingConfig.preTokenizer, start, end)
+		return -1, -1, fmt.Errorf("[%d, %d) contains white space: %w", start, end,
+			ErrInvalidWordBounds)
+	}
+	return start, end, nil
+}
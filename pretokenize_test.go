@@ -0,0 +1,204 @@
+package bpe
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func splitWords(preTokenizer PreTokenizer, sentence string) []string {
+	var words []string
+	for start, end := preTokenizer.NextWord(sentence, 0); start != -1; {
+		words = append(words, sentence[start:end])
+		start, end = preTokenizer.NextWord(sentence, end)
+	}
+	return words
+}
+
+func TestWhitespacePreTokenizer(t *testing.T) {
+	req := require.New(t)
+	for _, sentence := range []string{"", " \n", "ab", "  aé,b  b.cd\n", "a b\tc "} {
+		words := splitWords(WhitespacePreTokenizer{}, sentence)
+		if len(words) == 0 {
+			words = []string{}
+		}
+		req.Equal(strings.Fields(sentence), words, sentence)
+	}
+}
+
+func TestPunctuationPreTokenizer(t *testing.T) {
+	req := require.New(t)
+	req.Equal([]string{"Hello", ",", "world", "!"},
+		splitWords(PunctuationPreTokenizer{}, "Hello, world!"))
+	req.Equal([]string{"«", "don", "'", "t", "»", "—", "2", "+", "2", "=", "4", "€"},
+		splitWords(PunctuationPreTokenizer{}, " «don't» —\t2+2=4€ "))
+	req.Equal([]string{"日本語", "。"}, splitWords(PunctuationPreTokenizer{}, "日本語。"))
+	req.Nil(splitWords(PunctuationPreTokenizer{}, "  "))
+}
+
+func TestCodePreTokenizer(t *testing.T) {
+	req := require.New(t)
+	req.Equal([]string{"parse", "HTTP", "Request", "(", "req", "_", "id", "2", ")"},
+		splitWords(CodePreTokenizer{}, "parseHTTPRequest(req_id2)"))
+	req.Equal([]string{"x", ":", "=", "my", "Var", "[", "i", "]", ".", "Len", "(", ")"},
+		splitWords(CodePreTokenizer{}, "x := myVar[i].Len()"))
+	req.Equal([]string{"MAX", "_", "SIZE", "=", "1024", "ABC"},
+		splitWords(CodePreTokenizer{}, "MAX_SIZE = 1024ABC"))
+	req.Equal([]string{"Ünïcode", "Çase", "v", "1", "beta"},
+		splitWords(CodePreTokenizer{}, "ÜnïcodeÇase v1beta"))
+	req.Nil(splitWords(CodePreTokenizer{}, ""))
+}
+
+func TestWithPreTokenizer(t *testing.T) {
+	req := require.New(t)
+	opts := DefaultTrainOptions()
+	opts.PreTokenizer = CodePreTokenizer{}
+	model, err := Train(strings.NewReader("getValue setValue\nvalue_of(getValue)"), 24, opts)
+	req.NoError(err)
+	config := NewEncodingConfig(WithPreTokenizer(CodePreTokenizer{}))
+
+	subwords, err := model.EncodeSentenceToSubwords("setValue(value)", config)
+	req.NoError(err)
+	req.Equal([]string{"▁", "s", "et", "Value", "(", "v", "alue", ")"}, subwords)
+	encoded, offsets, err := model.EncodeSentenceWithOffsets("setValue(value)", config)
+	req.NoError(err)
+	req.Equal([]Offset{{0, 0}, {0, 1}, {1, 3}, {3, 8}, {8, 9}, {9, 10}, {10, 14}, {14, 15}},
+		offsets)
+	// The attached words are glued back together
+	decoded, err := model.DecodeSentence(encoded, NewDecodingConfig())
+	req.NoError(err)
+	req.Equal("setValue(value)", decoded)
+
+	// The cache distinguishes the attached words from the ones which follow white space
+	cache := NewWordCache(10)
+	cachedConfig := NewEncodingConfig(WithPreTokenizer(CodePreTokenizer{}), WithWordCache(cache))
+	for i := 0; i < 2; i++ {
+		for _, sentence := range []string{"setValue(value)", "value Value"} {
+			expected, err := model.EncodeSentence(sentence, config)
+			req.NoError(err)
+			encoded, err := model.EncodeSentence(sentence, cachedConfig)
+			req.NoError(err)
+			req.Equal(expected, encoded)
+		}
+	}
+	req.Equal(CacheStats{Hits: 7, Misses: 7, Len: 7}, cache.Stats())
+
+	// WhitespacePreTokenizer is the same as the default
+	expected, err := BPE.EncodeSentence("abcda bdhsab acad", NewEncodingConfig())
+	req.NoError(err)
+	encoded, err = BPE.EncodeSentence("abcda bdhsab acad",
+		NewEncodingConfig(WithPreTokenizer(WhitespacePreTokenizer{})))
+	req.NoError(err)
+	req.Equal(expected, encoded)
+}
+
+// brokenPreTokenizer returns the same bounds for every position
+type brokenPreTokenizer struct {
+	start, end int
+}
+
+func (bpt brokenPreTokenizer) NextWord(sentence string, pos int) (start, end int) {
+	return bpt.start, bpt.end
+}
+
+// restPreTokenizer returns the rest of the sentence as a single word
+type restPreTokenizer struct{}
+
+func (restPreTokenizer) NextWord(sentence string, pos int) (start, end int) {
+	if pos == len(sentence) {
+		return -1, -1
+	}
+	return pos, len(sentence)
+}
+
+func TestWithPreTokenizer_Broken(t *testing.T) {
+	req := require.New(t)
+	for _, preTokenizer := range []PreTokenizer{
+		// Empty word
+		brokenPreTokenizer{1, 1},
+		// The word ends before it starts
+		brokenPreTokenizer{2, 1},
+		// The same word forever
+		brokenPreTokenizer{0, 2},
+		// Out of the sentence
+		brokenPreTokenizer{0, 100},
+		brokenPreTokenizer{-2, 1},
+		// White space inside the word
+		restPreTokenizer{},
+	} {
+		for _, withOffsets := range []bool{false, true} {
+			config := NewEncodingConfig(WithBOS(), WithPreTokenizer(preTokenizer))
+			dst := EncodedString{5}
+			var encoded EncodedString
+			var offsets []Offset
+			var err error
+			if withOffsets {
+				encoded, offsets, err = BPE.appendEncoded(&encodingBuffers{}, dst, nil,
+					"abcd ab", config, true)
+			} else {
+				encoded, err = BPE.NewEncoder(config).AppendEncode(dst, "abcd ab")
+			}
+			req.True(errors.Is(err, ErrInvalidWordBounds), "%v %v", preTokenizer, err)
+			req.Equal(EncodedString{5}, encoded)
+			req.Empty(offsets)
+		}
+
+		opts := DefaultTrainOptions()
+		opts.PreTokenizer = preTokenizer
+		_, err := Train(strings.NewReader("abcd ab"), 10, opts)
+		req.True(errors.Is(err, ErrInvalidWordBounds), "%v %v", preTokenizer, err)
+	}
+}
+
+func TestParsePreTokenizer(t *testing.T) {
+	req := require.New(t)
+	for _, preTokenizer := range []PreTokenizer{
+		WhitespacePreTokenizer{}, PunctuationPreTokenizer{}, CodePreTokenizer{},
+	} {
+		name, ok := PreTokenizerName(preTokenizer)
+		req.True(ok)
+		parsed, err := ParsePreTokenizer(name + "\n")
+		req.NoError(err)
+		req.Equal(preTokenizer, parsed)
+	}
+	_, ok := PreTokenizerName(brokenPreTokenizer{})
+	req.False(ok)
+	_, err := ParsePreTokenizer("words")
+	req.True(errors.Is(err, ErrUnknownPreTokenizer))
+}
+
+func TestReadPreTokenizer(t *testing.T) {
+	req := require.New(t)
+	dir, err := ioutil.TempDir("", "pretokenizer")
+	req.NoError(err)
+	defer os.RemoveAll(dir)
+	modelPath := filepath.Join(dir, "model.yttm")
+
+	preTokenizer, err := ReadPreTokenizer(modelPath)
+	req.NoError(err)
+	req.Nil(preTokenizer)
+	req.NoError(WritePreTokenizer(modelPath, CodePreTokenizer{}))
+	preTokenizer, err = ReadPreTokenizer(modelPath)
+	req.NoError(err)
+	req.Equal(CodePreTokenizer{}, preTokenizer)
+
+	err = WritePreTokenizer(modelPath, brokenPreTokenizer{})
+	req.True(errors.Is(err, ErrUnknownPreTokenizer))
+	req.NoError(ioutil.WriteFile(modelPath+preTokenizerSuffix, []byte("words"), 0666))
+	_, err = ReadPreTokenizer(modelPath)
+	req.True(errors.Is(err, ErrUnknownPreTokenizer))
+}
+
+func TestEncoder_AppendEncodeWithPreTokenizerDoesNotAllocate(t *testing.T) {
+	encoder := BPE.NewEncoder(NewEncodingConfig(WithPreTokenizer(PunctuationPreTokenizer{})))
+	dst := make(EncodedString, 0, 100)
+	allocs := testing.AllocsPerRun(100, func() {
+		_, _ = encoder.AppendEncode(dst[:0], "abcda, bdhsab. acad!")
+	})
+	require.Equal(t, 0.0, allocs)
+}
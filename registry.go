@@ -0,0 +1,269 @@
+package bpe
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ModelExtension is the extension of the model files which Registry loads
+const ModelExtension = ".yttm"
+
+// DefaultPollInterval is the default period of the checks of Registry for changed files
+const DefaultPollInterval = 10 * time.Second
+
+// Registry keeps the models stored as binary dumps in a directory, named after their files
+// without ModelExtension, and reloads them when the files or the settings recorded next to them
+// change. Every check compares the size, the modification time and the identity of the files
+// with the previous ones and reads only the files which differ, a model is parsed again only if
+// their contents have changed. The models are replaced atomically: the callers which have got
+// a model keep using it while the new version is served to the next callers. If a changed file
+// cannot be read or is rejected by ReadModelStrict, the previous version of the model stays.
+// Registry is safe for concurrent use.
+type Registry struct {
+	dir          string
+	pollInterval time.Duration
+	// snapshot is *registrySnapshot which is never modified, every change stores a new one
+	snapshot atomic.Value
+	// reloadLock serializes the reloads and guards loaded
+	reloadLock sync.Mutex
+	loaded     map[string]loadedFile
+	stop       chan struct{}
+	stopped    chan struct{}
+	closeOnce  sync.Once
+}
+
+// registrySnapshot is the state of Registry after a reload
+type registrySnapshot struct {
+	models map[string]*Model
+	files  map[string]ModelFile
+}
+
+// loadedFile is what Registry remembers about a model file and its records which have been
+// loaded or have failed to load, so that they are read and parsed again only after they change
+type loadedFile struct {
+	version fileVersion
+	stats   modelFileStats
+	// read is when the contents were read
+	read time.Time
+}
+
+// fileVersion is the hash of the contents of a model file and its records. The files whose
+// metadata have changed are parsed again only if the hash has changed too.
+type fileVersion [sha256.Size]byte
+
+// modelFileStats are the metadata of a model file and its records, nil for the missing records
+type modelFileStats [3]os.FileInfo
+
+// modTimeGranularity is the coarsest resolution of the modification times of the file systems
+const modTimeGranularity = 2 * time.Second
+
+func statModelFile(path string) (modelFileStats, error) {
+	var stats modelFileStats
+	for i, suffix := range []string{"", normalizationSuffix, preTokenizerSuffix} {
+		info, err := os.Stat(path + suffix)
+		if err != nil && (i == 0 || !os.IsNotExist(err)) {
+			return stats, err
+		}
+		stats[i] = info
+	}
+	return stats, nil
+}
+
+// unchanged reports whether the files with the given metadata must have the same contents as
+// the loaded ones. The file which was modified around the time when it was read may have been
+// rewritten with the same size and modification time, it is read again to be sure.
+func (f loadedFile) unchanged(stats modelFileStats) bool {
+	for i, info := range stats {
+		previous := f.stats[i]
+		if info == nil || previous == nil {
+			if info != previous {
+				return false
+			}
+			continue
+		}
+		if info.Size() != previous.Size() || !info.ModTime().Equal(previous.ModTime()) ||
+			!os.SameFile(info, previous) ||
+			!info.ModTime().Before(f.read.Add(-modTimeGranularity)) {
+			return false
+		}
+	}
+	return true
+}
+
+// RegistryOption is a setting of Registry
+type RegistryOption func(*Registry)
+
+// WithPollInterval sets how often Registry checks the directory for changes. The checks are
+// disabled if the interval is not positive, Reload must be called explicitly then.
+// DefaultPollInterval is used by default.
+func WithPollInterval(interval time.Duration) RegistryOption {
+	return func(r *Registry) {
+		r.pollInterval = interval
+	}
+}
+
+// NewRegistry loads the models from the directory and starts watching it for changes.
+// It fails if any model cannot be loaded. The registry must be closed to stop the watching.
+func NewRegistry(dir string, options ...RegistryOption) (*Registry, error) {
+	r := &Registry{
+		dir:          dir,
+		pollInterval: DefaultPollInterval,
+		loaded:       map[string]loadedFile{},
+		stop:         make(chan struct{}),
+		stopped:      make(chan struct{}),
+	}
+	for _, option := range options {
+		option(r)
+	}
+	r.snapshot.Store(&registrySnapshot{map[string]*Model{}, map[string]ModelFile{}})
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	if r.pollInterval > 0 {
+		go r.watch()
+	} else {
+		close(r.stopped)
+	}
+	return r, nil
+}
+
+// Get returns the current version of the model with the given name
+func (r *Registry) Get(name string) (*Model, bool) {
+	model, ok := r.Models()[name]
+	return model, ok
+}
+
+// Models returns the current versions of all the models by their names. The map is a snapshot
+// which does not change with the reloads and must not be modified.
+func (r *Registry) Models() map[string]*Model {
+	return r.snapshot.Load().(*registrySnapshot).models
+}
+
+// Files returns the current versions of all the models together with their recorded settings,
+// the same way as Models does
+func (r *Registry) Files() map[string]ModelFile {
+	return r.snapshot.Load().(*registrySnapshot).files
+}
+
+// Names returns the sorted names of the current models
+func (r *Registry) Names() []string {
+	models := r.Models()
+	names := make([]string, 0, len(models))
+	for name := range models {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Reload checks the directory once: it loads the new and the changed model files, as well as
+// the models whose recorded normalization or pre-tokenizer has changed, and forgets the models
+// whose files have been removed. A model whose file fails to load keeps its previous version.
+// The failed file is not parsed again until it changes. Reload returns the first error, all of
+// them are logged.
+func (r *Registry) Reload() error {
+	r.reloadLock.Lock()
+	defer r.reloadLock.Unlock()
+	infos, err := ioutil.ReadDir(r.dir)
+	if err != nil {
+		logger.Errorf("Failed to list the models in %s: %v", r.dir, err)
+		return err
+	}
+	current := r.Files()
+	files := make(map[string]ModelFile, len(infos))
+	loaded := make(map[string]loadedFile, len(infos))
+	var firstErr error
+	for _, info := range infos {
+		name := strings.TrimSuffix(info.Name(), ModelExtension)
+		if !info.Mode().IsRegular() || name == info.Name() || strings.HasPrefix(name, ".") {
+			continue
+		}
+		path := filepath.Join(r.dir, info.Name())
+		file, changed, err := r.reloadFile(name, path, loaded)
+		if err == nil && !changed {
+			if file, ok := current[name]; ok {
+				files[name] = file
+			}
+			continue
+		}
+		if err != nil {
+			err = fmt.Errorf("%s: %w", path, err)
+			logger.Errorf("Failed to reload the model %s: %v", name, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			if file, ok := current[name]; ok {
+				files[name] = file
+			}
+			continue
+		}
+		files[name] = file
+	}
+	models := make(map[string]*Model, len(files))
+	for name, file := range files {
+		models[name] = file.Model
+	}
+	r.loaded = loaded
+	r.snapshot.Store(&registrySnapshot{models, files})
+	return firstErr
+}
+
+// reloadFile reads and parses the model file if it has changed since the previous reload and
+// remembers it in loaded. changed is false if the current version of the model stays.
+func (r *Registry) reloadFile(name, path string, loaded map[string]loadedFile) (
+	file ModelFile, changed bool, err error) {
+	// The metadata go first, so that a change during the reading is noticed by the next reload
+	stats, err := statModelFile(path)
+	if err != nil {
+		return file, true, err
+	}
+	previous, ok := r.loaded[name]
+	if ok && previous.unchanged(stats) {
+		loaded[name] = previous
+		return file, false, nil
+	}
+	read := time.Now()
+	contents, err := readModelFileContents(path)
+	if err != nil {
+		return file, true, err
+	}
+	version := contents.version()
+	loaded[name] = loadedFile{version, stats, read}
+	if ok && previous.version == version {
+		return file, false, nil
+	}
+	file, err = contents.parse()
+	return file, true, err
+}
+
+// Close stops watching the directory. The models stay available.
+func (r *Registry) Close() error {
+	r.closeOnce.Do(func() {
+		close(r.stop)
+	})
+	<-r.stopped
+	return nil
+}
+
+func (r *Registry) watch() {
+	defer close(r.stopped)
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			// The errors are logged and the previous models are kept
+			_ = r.Reload()
+		}
+	}
+}
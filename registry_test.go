@@ -0,0 +1,157 @@
+package bpe
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// writeRegistryFile writes the contents into the directory with the same old modification time
+// every time, so that only the sizes tell the versions apart by the metadata
+func writeRegistryFile(t *testing.T, dir, name string, contents []byte) {
+	writeRegistryFileAt(t, dir, name, contents, time.Unix(1500000000, 0))
+}
+
+func writeRegistryFileAt(t *testing.T, dir, name string, contents []byte, modTime time.Time) {
+	path := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(path, contents, 0666))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func modelBytes(t *testing.T, text string, vocabSize int) []byte {
+	model, err := Train(strings.NewReader(text), vocabSize, DefaultTrainOptions())
+	require.NoError(t, err)
+	var buf strings.Builder
+	_, err = model.WriteTo(&buf)
+	require.NoError(t, err)
+	return []byte(buf.String())
+}
+
+func TestRegistry_Reload(t *testing.T) {
+	req := require.New(t)
+	dir, err := ioutil.TempDir("", "registry")
+	req.NoError(err)
+	defer os.RemoveAll(dir)
+	writeRegistryFile(t, dir, "ab.yttm", modelBytes(t, "aaab aab\nab", 10))
+	writeRegistryFile(t, dir, "xy.yttm", modelBytes(t, "xyz xy", 9))
+	writeRegistryFile(t, dir, "notes.txt", []byte("not a model"))
+	req.NoError(os.Mkdir(filepath.Join(dir, "old.yttm"), 0777))
+
+	registry, err := NewRegistry(dir, WithPollInterval(0))
+	req.NoError(err)
+	defer registry.Close()
+	req.Equal([]string{"ab", "xy"}, registry.Names())
+	ab, ok := registry.Get("ab")
+	req.True(ok)
+	req.Equal(10, ab.VocabSize())
+	_, ok = registry.Get("notes")
+	req.False(ok)
+
+	// The callers which hold the previous version are not affected by the swap
+	writeRegistryFile(t, dir, "ab.yttm", modelBytes(t, "aaab aab\nab", 8))
+	req.NoError(registry.Reload())
+	newAB, _ := registry.Get("ab")
+	req.Equal(8, newAB.VocabSize())
+	req.Equal(10, ab.VocabSize())
+	xy, _ := registry.Get("xy")
+	req.Equal(registry.Models()["xy"], xy)
+
+	writeRegistryFile(t, dir, "ab.yttm", modelBytes(t, "aaab aab\nab", 10)[:30])
+	err = registry.Reload()
+	req.True(errors.Is(err, ErrTruncatedModel))
+	model, _ := registry.Get("ab")
+	req.Equal(newAB, model)
+	// The broken file is not parsed again until it changes
+	req.NoError(registry.Reload())
+
+	writeRegistryFile(t, dir, "ab.yttm", append(modelBytes(t, "aaab aab\nab", 10), 0))
+	err = registry.Reload()
+	req.True(errors.Is(err, ErrInvalidModel))
+	model, _ = registry.Get("ab")
+	req.Equal(newAB, model)
+
+	writeRegistryFile(t, dir, "ab.yttm", dumpToBinary(modelDump{
+		[]dumpChar{{'a', 4}, {'b', 4}},
+		nil,
+		specialTokens{1, 0, 2, 3},
+	}))
+	err = registry.Reload()
+	req.True(errors.Is(err, ErrInvalidModel))
+	model, _ = registry.Get("ab")
+	req.Equal(newAB, model)
+
+	// The rewrite of the same size and modification time is noticed only if the file was
+	// modified around the time when it was read
+	abBytes := modelBytes(t, "aaab aab\nab", 8)
+	baBytes := modelBytes(t, "bbba bba\nba", 8)
+	req.Len(baBytes, len(abBytes))
+	now := time.Now()
+	writeRegistryFileAt(t, dir, "ab.yttm", abBytes, now)
+	req.NoError(registry.Reload())
+	model, _ = registry.Get("ab")
+	req.Equal([]string{"a", "b"}, model.Vocab()[4:6])
+	writeRegistryFileAt(t, dir, "ab.yttm", baBytes, now)
+	req.NoError(registry.Reload())
+	model, _ = registry.Get("ab")
+	req.Equal([]string{"b", "a"}, model.Vocab()[4:6])
+	writeRegistryFile(t, dir, "ab.yttm", abBytes)
+	req.NoError(registry.Reload())
+	model, _ = registry.Get("ab")
+	req.Equal([]string{"a", "b"}, model.Vocab()[4:6])
+	// The old files with the same metadata are not read again
+	writeRegistryFile(t, dir, "ab.yttm", baBytes)
+	req.NoError(registry.Reload())
+	req.Equal(model, registry.Models()["ab"])
+
+	// The change of the recorded normalization reloads the model too
+	req.Nil(registry.Files()["ab"].Normalization)
+	req.NoError(WriteNormalization(filepath.Join(dir, "ab.yttm"), Normalization{Lowercase}))
+	req.NoError(registry.Reload())
+	req.Equal(Normalization{Lowercase}, registry.Files()["ab"].Normalization)
+	req.Equal(registry.Models()["ab"], registry.Files()["ab"].Model)
+	req.NoError(ioutil.WriteFile(filepath.Join(dir, "ab.yttm.norm"), []byte("upper"), 0666))
+	err = registry.Reload()
+	req.True(errors.Is(err, ErrUnknownNormalization))
+	req.Equal(Normalization{Lowercase}, registry.Files()["ab"].Normalization)
+	req.NoError(WritePreTokenizer(filepath.Join(dir, "ab.yttm"), CodePreTokenizer{}))
+	req.NoError(WriteNormalization(filepath.Join(dir, "ab.yttm"), nil))
+	req.NoError(registry.Reload())
+	req.Equal(CodePreTokenizer{}, registry.Files()["ab"].PreTokenizer)
+	req.Nil(registry.Files()["ab"].Normalization)
+
+	req.NoError(os.Remove(filepath.Join(dir, "xy.yttm")))
+	writeRegistryFile(t, dir, "cd.yttm", modelBytes(t, "cdd cd", 8))
+	req.NoError(registry.Reload())
+	req.Equal([]string{"ab", "cd"}, registry.Names())
+}
+
+func TestNewRegistry(t *testing.T) {
+	req := require.New(t)
+	dir, err := ioutil.TempDir("", "registry")
+	req.NoError(err)
+	defer os.RemoveAll(dir)
+
+	_, err = NewRegistry(filepath.Join(dir, "missing"))
+	req.Error(err)
+
+	writeRegistryFile(t, dir, "ab.yttm", []byte{1, 2, 3})
+	_, err = NewRegistry(dir)
+	req.Error(err)
+
+	writeRegistryFile(t, dir, "ab.yttm", modelBytes(t, "aaab aab\nab", 10))
+	registry, err := NewRegistry(dir, WithPollInterval(time.Millisecond))
+	req.NoError(err)
+	writeRegistryFile(t, dir, "ab.yttm", modelBytes(t, "aaab aab\nab", 8))
+	req.Eventually(func() bool {
+		model, _ := registry.Get("ab")
+		return model.VocabSize() == 8
+	}, 10*time.Second, time.Millisecond)
+	req.NoError(registry.Close())
+	req.NoError(registry.Close())
+}
@@ -0,0 +1,257 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	bpe "github.com/src-d/go-YouTokenToMe"
+	"github.com/src-d/go-YouTokenToMe/tokenizerpb"
+)
+
+// RegisterTokenizerServer registers the gRPC service tokenizerpb.Tokenizer which serves the same
+// models as the HTTP endpoints. The lines of the streaming RPCs are limited to the maximal size
+// of the request bodies.
+func (s *Server) RegisterTokenizerServer(registrar grpc.ServiceRegistrar) {
+	tokenizerpb.RegisterTokenizerServer(registrar, &tokenizerServer{server: s})
+}
+
+// tokenizerServer implements tokenizerpb.TokenizerServer on top of Server
+type tokenizerServer struct {
+	tokenizerpb.UnimplementedTokenizerServer
+	server *Server
+}
+
+func (ts *tokenizerServer) Encode(ctx context.Context, request *tokenizerpb.EncodeRequest) (
+	*tokenizerpb.EncodeResponse, error) {
+	file, err := ts.server.model(request.Model)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	model := file.Model
+	options := request.GetOptions()
+	if err := checkOutputType(options.GetOutputType()); err != nil {
+		return nil, err
+	}
+	ids, err := model.EncodeSentences(request.Sentences, newProtoEncodingConfig(file, options))
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	response := &tokenizerpb.EncodeResponse{
+		Encodings: make([]*tokenizerpb.Encoding, len(ids)),
+	}
+	for i, encoded := range ids {
+		if response.Encodings[i], err = newEncoding(model, encoded,
+			options.GetOutputType()); err != nil {
+			return nil, err
+		}
+	}
+	return response, nil
+}
+
+func (ts *tokenizerServer) Decode(ctx context.Context, request *tokenizerpb.DecodeRequest) (
+	*tokenizerpb.DecodeResponse, error) {
+	file, err := ts.server.model(request.Model)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	ids := make([]bpe.EncodedString, len(request.Sentences))
+	for i, sentence := range request.Sentences {
+		ids[i] = make(bpe.EncodedString, len(sentence.GetIds()))
+		for j, id := range sentence.GetIds() {
+			ids[i][j] = bpe.TokenID(id)
+		}
+	}
+	sentences, err := file.Model.DecodeSentences(ids,
+		newProtoDecodingConfig(request.GetOptions()))
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &tokenizerpb.DecodeResponse{Sentences: sentences}, nil
+}
+
+func (ts *tokenizerServer) EncodeStream(stream tokenizerpb.Tokenizer_EncodeStreamServer) error {
+	first, err := stream.Recv()
+	if err == io.EOF {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	file, err := ts.server.model(first.Model)
+	if err != nil {
+		return grpcError(err)
+	}
+	model := file.Model
+	options := first.GetOptions()
+	if err := checkOutputType(options.GetOutputType()); err != nil {
+		return err
+	}
+	reader := receiveData(first.Data, func() ([]byte, error) {
+		request, err := stream.Recv()
+		return request.GetData(), err
+	})
+	defer reader.Close()
+	encoder := model.NewStreamEncoder(reader, newProtoEncodingConfig(file, options))
+	encoder.SetMaxLineLength(int(ts.server.maxBodyBytes))
+	for line := int64(1); encoder.Next(); line++ {
+		encoding, err := newEncoding(model, encoder.Encoded(), options.GetOutputType())
+		if err != nil {
+			return err
+		}
+		err = stream.Send(&tokenizerpb.EncodeStreamResponse{Line: line, Encoding: encoding})
+		if err != nil {
+			return err
+		}
+	}
+	return streamError(encoder.Err())
+}
+
+func (ts *tokenizerServer) DecodeStream(stream tokenizerpb.Tokenizer_DecodeStreamServer) error {
+	first, err := stream.Recv()
+	if err == io.EOF {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	file, err := ts.server.model(first.Model)
+	if err != nil {
+		return grpcError(err)
+	}
+	reader := receiveData(first.Data, func() ([]byte, error) {
+		request, err := stream.Recv()
+		return request.GetData(), err
+	})
+	defer reader.Close()
+	decoder := file.Model.NewStreamDecoder(reader, newProtoDecodingConfig(first.GetOptions()))
+	decoder.SetMaxLineLength(int(ts.server.maxBodyBytes))
+	for line := int64(1); decoder.Next(); line++ {
+		err := stream.Send(&tokenizerpb.DecodeStreamResponse{
+			Line: line, Sentence: decoder.Decoded(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return streamError(decoder.Err())
+}
+
+func (ts *tokenizerServer) GetVocab(ctx context.Context, request *tokenizerpb.GetVocabRequest) (
+	*tokenizerpb.GetVocabResponse, error) {
+	file, err := ts.server.model(request.Model)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	tokens := vocabTokens(file.Model)
+	response := &tokenizerpb.GetVocabResponse{Tokens: make([]*tokenizerpb.Token, len(tokens))}
+	for i, token := range tokens {
+		response.Tokens[i] = &tokenizerpb.Token{Id: uint32(token.ID), Token: token.Token}
+	}
+	return response, nil
+}
+
+// receiveData writes the chunks of a client stream into a pipe, so that they can be read
+// line by line. The reader must be closed to stop receiving.
+func receiveData(first []byte, recv func() ([]byte, error)) *io.PipeReader {
+	reader, writer := io.Pipe()
+	go func() {
+		data := first
+		for {
+			if _, err := writer.Write(data); err != nil {
+				return
+			}
+			var err error
+			data, err = recv()
+			if err == io.EOF {
+				writer.Close()
+				return
+			} else if err != nil {
+				writer.CloseWithError(err)
+				return
+			}
+		}
+	}()
+	return reader
+}
+
+func newProtoEncodingConfig(file bpe.ModelFile,
+	options *tokenizerpb.EncodeOptions) bpe.EncodingConfig {
+	var seed *int64
+	if options != nil {
+		seed = options.Seed
+	}
+	return newEncodingConfig(file, options.GetBos(), options.GetEos(), options.GetReverse(),
+		options.GetDropout(), seed)
+}
+
+func newProtoDecodingConfig(options *tokenizerpb.DecodeOptions) bpe.DecodingConfig {
+	return newDecodingConfig(options.GetSkipSpecialTokens(), options.GetStopAtEos(),
+		options.GetReverse())
+}
+
+func checkOutputType(outputType tokenizerpb.OutputType) error {
+	if _, ok := tokenizerpb.OutputType_name[int32(outputType)]; !ok {
+		return status.Errorf(codes.InvalidArgument, "unknown output type %d", outputType)
+	}
+	return nil
+}
+
+func newEncoding(model *bpe.Model, encoded bpe.EncodedString,
+	outputType tokenizerpb.OutputType) (*tokenizerpb.Encoding, error) {
+	if outputType == tokenizerpb.OutputType_OUTPUT_TYPE_SUBWORD {
+		subwords := make([]string, len(encoded))
+		for i, id := range encoded {
+			subword, err := model.IDToToken(id, false)
+			if err != nil {
+				return nil, status.Error(codes.Internal, err.Error())
+			}
+			subwords[i] = subword
+		}
+		return &tokenizerpb.Encoding{Subwords: subwords}, nil
+	}
+	ids := make([]uint32, len(encoded))
+	for i, id := range encoded {
+		ids[i] = uint32(id)
+	}
+	return &tokenizerpb.Encoding{Ids: ids}, nil
+}
+
+// grpcError converts the errors of the HTTP handlers to gRPC statuses
+func grpcError(err error) error {
+	code := codes.Internal
+	var statusErr *httpError
+	if errors.As(err, &statusErr) {
+		switch statusErr.status {
+		case http.StatusBadRequest:
+			code = codes.InvalidArgument
+		case http.StatusNotFound:
+			code = codes.NotFound
+		}
+	}
+	return status.Error(code, err.Error())
+}
+
+// streamError converts the error of StreamEncoder or StreamDecoder to a gRPC status. The errors
+// of the lines which are caused by the client are InvalidArgument. The others come from
+// receiving the stream, e.g. the cancellation by the client, and keep their status.
+func streamError(err error) error {
+	var numErr *strconv.NumError
+	if errors.Is(err, bpe.ErrLineTooLong) || errors.As(err, &numErr) ||
+		errors.Is(err, bpe.ErrUnknownTokenID) || errors.Is(err, bpe.ErrSpecialTokenMissing) ||
+		errors.Is(err, bpe.ErrInvalidDropout) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	// The errors of receiving are wrapped into *bpe.LineError
+	for cause := errors.Unwrap(err); cause != nil; cause = errors.Unwrap(err) {
+		err = cause
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return status.FromContextError(err).Err()
+}
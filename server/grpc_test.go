@@ -0,0 +1,301 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	bpe "github.com/src-d/go-YouTokenToMe"
+	"github.com/src-d/go-YouTokenToMe/tokenizerpb"
+)
+
+// newTestClient serves the models of newTestServer over an in-memory connection
+func newTestClient(t *testing.T, options ...Option) (tokenizerpb.TokenizerClient, func()) {
+	return newTestClientWithServerOptions(t, nil, options...)
+}
+
+// newTestClientWithServerOptions works like newTestClient but also sets up the gRPC server
+func newTestClientWithServerOptions(t *testing.T, serverOptions []grpc.ServerOption,
+	options ...Option) (tokenizerpb.TokenizerClient, func()) {
+	model, err := bpe.Train(strings.NewReader("aaab aab\nab"), 10, bpe.DefaultTrainOptions())
+	require.NoError(t, err)
+	other, err := bpe.Train(strings.NewReader("xyz xy"), 9, bpe.DefaultTrainOptions())
+	require.NoError(t, err)
+	grpcServer := grpc.NewServer(serverOptions...)
+	NewWithModelFiles(map[string]bpe.ModelFile{
+		"ab": {Model: model},
+		"xy": {Model: other},
+		"AB": {Model: model, Normalization: bpe.Normalization{bpe.Lowercase}},
+	}, options...).RegisterTokenizerServer(grpcServer)
+	listener := bufconn.Listen(1 << 20)
+	go grpcServer.Serve(listener)
+	conn, err := grpc.Dial("bufconn", grpc.WithInsecure(),
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return listener.Dial()
+		}))
+	require.NoError(t, err)
+	return tokenizerpb.NewTokenizerClient(conn), func() {
+		conn.Close()
+		grpcServer.Stop()
+	}
+}
+
+func TestTokenizerServer_Encode(t *testing.T) {
+	req := require.New(t)
+	client, cleanup := newTestClient(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	response, err := client.Encode(ctx, &tokenizerpb.EncodeRequest{
+		Model:     "ab",
+		Sentences: []string{"aab ab aaab", "ab", ""},
+		Options:   &tokenizerpb.EncodeOptions{Bos: true},
+	})
+	req.NoError(err)
+	req.Len(response.Encodings, 3)
+	req.Equal([]uint32{2, 9, 5, 6, 8, 9, 8}, response.Encodings[0].Ids)
+	req.Equal([]uint32{2, 6, 8}, response.Encodings[1].Ids)
+	req.Equal([]uint32{2}, response.Encodings[2].Ids)
+	req.Nil(response.Encodings[0].Subwords)
+
+	response, err = client.Encode(ctx, &tokenizerpb.EncodeRequest{
+		Model:     "AB",
+		Sentences: []string{"AAB Ab aaab"},
+	})
+	req.NoError(err)
+	req.Equal([]uint32{9, 5, 6, 8, 9, 8}, response.Encodings[0].Ids)
+
+	response, err = client.Encode(ctx, &tokenizerpb.EncodeRequest{
+		Model:     "ab",
+		Sentences: []string{"aab ab"},
+		Options: &tokenizerpb.EncodeOptions{
+			Eos: true, Reverse: true, OutputType: tokenizerpb.OutputType_OUTPUT_TYPE_SUBWORD,
+		},
+	})
+	req.NoError(err)
+	req.Equal([]string{"<EOS>", "ab", "▁", "b", "▁aa"}, response.Encodings[0].Subwords)
+
+	seed := int64(7)
+	request := &tokenizerpb.EncodeRequest{
+		Model:     "ab",
+		Sentences: []string{"aab ab aaab aaab aab"},
+		Options:   &tokenizerpb.EncodeOptions{Dropout: 0.5, Seed: &seed},
+	}
+	first, err := client.Encode(ctx, request)
+	req.NoError(err)
+	second, err := client.Encode(ctx, request)
+	req.NoError(err)
+	req.Equal(first.Encodings[0].Ids, second.Encodings[0].Ids)
+
+	for _, testCase := range []struct {
+		request *tokenizerpb.EncodeRequest
+		code    codes.Code
+	}{
+		{&tokenizerpb.EncodeRequest{Sentences: []string{"ab"}}, codes.InvalidArgument},
+		{&tokenizerpb.EncodeRequest{Model: "cd"}, codes.NotFound},
+		{&tokenizerpb.EncodeRequest{Model: "ab", Sentences: []string{"ab"},
+			Options: &tokenizerpb.EncodeOptions{Dropout: 2}}, codes.InvalidArgument},
+		{&tokenizerpb.EncodeRequest{Model: "ab",
+			Options: &tokenizerpb.EncodeOptions{OutputType: 5}}, codes.InvalidArgument},
+	} {
+		_, err := client.Encode(ctx, testCase.request)
+		req.Equal(testCase.code, status.Code(err), testCase.request.String())
+	}
+}
+
+func TestTokenizerServer_Decode(t *testing.T) {
+	req := require.New(t)
+	client, cleanup := newTestClient(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	response, err := client.Decode(ctx, &tokenizerpb.DecodeRequest{
+		Model: "ab",
+		Sentences: []*tokenizerpb.TokenIDs{
+			{Ids: []uint32{9, 5, 6, 8, 9, 8}}, {Ids: []uint32{2, 9, 8, 3, 6, 4}}, {},
+		},
+	})
+	req.NoError(err)
+	req.Equal([]string{"aab ab aaab", "<BOS>aaab<EOS> a", ""}, response.Sentences)
+
+	response, err = client.Decode(ctx, &tokenizerpb.DecodeRequest{
+		Model:     "ab",
+		Sentences: []*tokenizerpb.TokenIDs{{Ids: []uint32{3, 8, 9, 2, 1}}},
+		Options: &tokenizerpb.DecodeOptions{
+			SkipSpecialTokens: true, StopAtEos: true, Reverse: true,
+		},
+	})
+	req.NoError(err)
+	req.Equal([]string{"aaab"}, response.Sentences)
+
+	_, err = client.Decode(ctx, &tokenizerpb.DecodeRequest{
+		Model: "ab", Sentences: []*tokenizerpb.TokenIDs{{Ids: []uint32{100}}},
+	})
+	req.Equal(codes.InvalidArgument, status.Code(err))
+}
+
+func TestTokenizerServer_EncodeStream(t *testing.T) {
+	req := require.New(t)
+	client, cleanup := newTestClient(t)
+	defer cleanup()
+
+	stream, err := client.EncodeStream(context.Background())
+	req.NoError(err)
+	req.NoError(stream.Send(&tokenizerpb.EncodeStreamRequest{
+		Model:   "ab",
+		Options: &tokenizerpb.EncodeOptions{Eos: true},
+		Data:    []byte("aab ab a"),
+	}))
+	req.NoError(stream.Send(&tokenizerpb.EncodeStreamRequest{Data: []byte("aab\r\n\nab\n")}))
+	// The first lines are encoded before the stream is over
+	for i, expected := range [][]uint32{{9, 5, 6, 8, 9, 8, 3}, {3}, {6, 8, 3}} {
+		response, err := stream.Recv()
+		req.NoError(err)
+		req.Equal(int64(i+1), response.Line)
+		req.Equal(expected, response.Encoding.Ids)
+	}
+	req.NoError(stream.Send(&tokenizerpb.EncodeStreamRequest{Data: []byte("a")}))
+	req.NoError(stream.Send(&tokenizerpb.EncodeStreamRequest{Data: []byte("b")}))
+	req.NoError(stream.CloseSend())
+	response, err := stream.Recv()
+	req.NoError(err)
+	req.Equal(int64(4), response.Line)
+	req.Equal([]uint32{6, 8, 3}, response.Encoding.Ids)
+	_, err = stream.Recv()
+	req.Equal(io.EOF, err)
+
+	stream, err = client.EncodeStream(context.Background())
+	req.NoError(err)
+	req.NoError(stream.Send(&tokenizerpb.EncodeStreamRequest{
+		Options: &tokenizerpb.EncodeOptions{
+			OutputType: tokenizerpb.OutputType_OUTPUT_TYPE_SUBWORD,
+		},
+		Model: "xy",
+		Data:  []byte("xyz"),
+	}))
+	req.NoError(stream.CloseSend())
+	response, err = stream.Recv()
+	req.NoError(err)
+	req.Equal([]string{"▁", "xy", "z"}, response.Encoding.Subwords)
+	_, err = stream.Recv()
+	req.Equal(io.EOF, err)
+
+	stream, err = client.EncodeStream(context.Background())
+	req.NoError(err)
+	req.NoError(stream.Send(&tokenizerpb.EncodeStreamRequest{Model: "AB", Data: []byte("AB")}))
+	req.NoError(stream.CloseSend())
+	response, err = stream.Recv()
+	req.NoError(err)
+	req.Equal([]uint32{6, 8}, response.Encoding.Ids)
+
+	stream, err = client.EncodeStream(context.Background())
+	req.NoError(err)
+	req.NoError(stream.Send(&tokenizerpb.EncodeStreamRequest{Model: "cd"}))
+	_, err = stream.Recv()
+	req.Equal(codes.NotFound, status.Code(err))
+
+	stream, err = client.EncodeStream(context.Background())
+	req.NoError(err)
+	req.NoError(stream.CloseSend())
+	_, err = stream.Recv()
+	req.Equal(io.EOF, err)
+}
+
+func TestTokenizerServer_EncodeStreamLongLines(t *testing.T) {
+	req := require.New(t)
+	client, cleanup := newTestClient(t, WithMaxBodyBytes(10))
+	defer cleanup()
+
+	stream, err := client.EncodeStream(context.Background())
+	req.NoError(err)
+	req.NoError(stream.Send(&tokenizerpb.EncodeStreamRequest{
+		Model: "ab",
+		Data:  []byte("ab\n" + strings.Repeat("ab ", 10) + "\nab\n"),
+	}))
+	response, err := stream.Recv()
+	req.NoError(err)
+	req.Equal([]uint32{6, 8}, response.Encoding.Ids)
+	_, err = stream.Recv()
+	req.Equal(codes.InvalidArgument, status.Code(err))
+	req.Contains(status.Convert(err).Message(), "line 2")
+}
+
+func TestTokenizerServer_EncodeStreamCanceled(t *testing.T) {
+	req := require.New(t)
+	handlerErrs := make(chan error, 1)
+	client, cleanup := newTestClientWithServerOptions(t, []grpc.ServerOption{
+		grpc.StreamInterceptor(func(server interface{}, stream grpc.ServerStream,
+			info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			err := handler(server, stream)
+			handlerErrs <- err
+			return err
+		}),
+	})
+	defer cleanup()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := client.EncodeStream(ctx)
+	req.NoError(err)
+	req.NoError(stream.Send(&tokenizerpb.EncodeStreamRequest{Model: "ab", Data: []byte("ab\na")}))
+	response, err := stream.Recv()
+	req.NoError(err)
+	req.Equal([]uint32{6, 8}, response.Encoding.Ids)
+	// The line which is being read when the client goes away is not the fault of the client
+	cancel()
+	err = <-handlerErrs
+	req.Equal(codes.Canceled, status.Code(err))
+	req.NotContains(status.Convert(err).Message(), "line 2")
+}
+
+func TestTokenizerServer_DecodeStream(t *testing.T) {
+	req := require.New(t)
+	client, cleanup := newTestClient(t)
+	defer cleanup()
+
+	stream, err := client.DecodeStream(context.Background())
+	req.NoError(err)
+	req.NoError(stream.Send(&tokenizerpb.DecodeStreamRequest{
+		Model:   "ab",
+		Options: &tokenizerpb.DecodeOptions{SkipSpecialTokens: true},
+		Data:    []byte("2 9 5 6 8 9 8 3\n\n6 "),
+	}))
+	req.NoError(stream.Send(&tokenizerpb.DecodeStreamRequest{Data: []byte("8\n9 x\n6 8")}))
+	req.NoError(stream.CloseSend())
+	for i, expected := range []string{"aab ab aaab", "", "ab"} {
+		response, err := stream.Recv()
+		req.NoError(err)
+		req.Equal(int64(i+1), response.Line)
+		req.Equal(expected, response.Sentence)
+	}
+	_, err = stream.Recv()
+	req.Equal(codes.InvalidArgument, status.Code(err))
+	req.Contains(status.Convert(err).Message(), "line 4")
+}
+
+func TestTokenizerServer_GetVocab(t *testing.T) {
+	req := require.New(t)
+	client, cleanup := newTestClient(t)
+	defer cleanup()
+
+	response, err := client.GetVocab(context.Background(), &tokenizerpb.GetVocabRequest{
+		Model: "xy",
+	})
+	req.NoError(err)
+	var tokens []string
+	for i, token := range response.Tokens {
+		req.Equal(uint32(i), token.Id)
+		tokens = append(tokens, token.Token)
+	}
+	req.Equal([]string{"<PAD>", "<UNK>", "<BOS>", "<EOS>", "x", "y", "▁", "z", "xy"}, tokens)
+
+	_, err = client.GetVocab(context.Background(), &tokenizerpb.GetVocabRequest{})
+	req.Equal(codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,417 @@
+// Package server exposes BPE models over HTTP with JSON bodies, so that the services written
+// in other languages can tokenize text without embedding the models. The endpoints are:
+//
+//	POST /encode  {"model": "en", "sentences": ["..."], "bos": true, "eos": true,
+//	               "reverse": false, "dropout": 0.1, "seed": 42, "output_type": "id"}
+//	           -> {"ids": [[...]]}, or {"subwords": [["..."]]} if output_type is "subword"
+//	POST /decode  {"model": "en", "ids": [[...]], "skip_special_tokens": true,
+//	               "stop_at_eos": false, "reverse": false}
+//	           -> {"sentences": ["..."]}
+//	GET  /vocab?model=en -> {"tokens": [{"id": 0, "token": "<PAD>"}, ...]}
+//	GET  /models  -> {"models": ["en", ...]}
+//	GET  /healthz -> 200 while the process is alive
+//	GET  /readyz  -> 200 while the server accepts requests, 503 after Drain
+//
+// "model" may be omitted if the server has a single model. The errors are reported as
+// {"error": "..."} with the corresponding HTTP status.
+//
+// Server.RegisterTokenizerServer serves the same models over gRPC with the service of package
+// tokenizerpb.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"sort"
+	"sync/atomic"
+
+	bpe "github.com/src-d/go-YouTokenToMe"
+)
+
+// DefaultMaxBodyBytes is the default limit of the size of the request bodies
+const DefaultMaxBodyBytes = 32 << 20
+
+// Server is http.Handler which serves the endpoints of the package
+type Server struct {
+	// models returns the current models, it is called once per request
+	models       func() map[string]bpe.ModelFile
+	maxBodyBytes int64
+	draining     int32
+	mux          *http.ServeMux
+}
+
+// Option is a setting of Server
+type Option func(*Server)
+
+// WithMaxBodyBytes limits the size of the request bodies, larger requests are rejected with
+// 413 status. DefaultMaxBodyBytes is used by default.
+func WithMaxBodyBytes(maxBodyBytes int64) Option {
+	return func(s *Server) {
+		s.maxBodyBytes = maxBodyBytes
+	}
+}
+
+// New creates Server which serves the given models by their names
+func New(models map[string]*bpe.Model, options ...Option) *Server {
+	files := make(map[string]bpe.ModelFile, len(models))
+	for name, model := range models {
+		files[name] = bpe.ModelFile{Model: model}
+	}
+	return NewWithModelFiles(files, options...)
+}
+
+// NewWithModelFiles creates Server which serves the given models by their names and encodes
+// the sentences with the recorded settings of the models
+func NewWithModelFiles(files map[string]bpe.ModelFile, options ...Option) *Server {
+	return newServer(func() map[string]bpe.ModelFile { return files }, options)
+}
+
+// NewWithRegistry creates Server which serves the models of the registry with their recorded
+// settings. Every request uses the version of the model which is current when the request
+// starts.
+func NewWithRegistry(registry *bpe.Registry, options ...Option) *Server {
+	return newServer(registry.Files, options)
+}
+
+func newServer(models func() map[string]bpe.ModelFile, options []Option) *Server {
+	s := &Server{
+		models:       models,
+		maxBodyBytes: DefaultMaxBodyBytes,
+		mux:          http.NewServeMux(),
+	}
+	for _, option := range options {
+		option(s)
+	}
+	s.mux.HandleFunc("/encode", s.handleEncode)
+	s.mux.HandleFunc("/decode", s.handleDecode)
+	s.mux.HandleFunc("/vocab", s.handleVocab)
+	s.mux.HandleFunc("/models", s.handleModels)
+	s.mux.HandleFunc("/healthz", s.handleHealth)
+	s.mux.HandleFunc("/readyz", s.handleReady)
+	return s
+}
+
+// ServeHTTP dispatches the request to the handler of the endpoint
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Drain makes /readyz fail, so that the load balancers stop sending new requests before
+// the server is shut down. The other endpoints keep working.
+func (s *Server) Drain() {
+	atomic.StoreInt32(&s.draining, 1)
+}
+
+// httpError is an error which is reported with the given HTTP status
+type httpError struct {
+	status int
+	err    error
+}
+
+func (e *httpError) Error() string {
+	return e.err.Error()
+}
+
+func badRequest(err error) error {
+	return &httpError{http.StatusBadRequest, err}
+}
+
+// EncodeRequest is the body of /encode requests
+type EncodeRequest struct {
+	Model     string   `json:"model"`
+	Sentences []string `json:"sentences"`
+	BOS       bool     `json:"bos"`
+	EOS       bool     `json:"eos"`
+	Reverse   bool     `json:"reverse"`
+	Dropout   float64  `json:"dropout"`
+	// Seed makes BPE-dropout reproducible, a random seed is used if it is not set
+	Seed       *int64 `json:"seed,omitempty"`
+	OutputType string `json:"output_type"`
+}
+
+// EncodeResponse is the body of /encode responses. Only one of the fields is set, depending on
+// the output type of the request; the other one is null.
+type EncodeResponse struct {
+	IDs      []bpe.EncodedString `json:"ids"`
+	Subwords [][]string          `json:"subwords"`
+}
+
+// DecodeRequest is the body of /decode requests
+type DecodeRequest struct {
+	Model             string              `json:"model"`
+	IDs               []bpe.EncodedString `json:"ids"`
+	SkipSpecialTokens bool                `json:"skip_special_tokens"`
+	StopAtEOS         bool                `json:"stop_at_eos"`
+	Reverse           bool                `json:"reverse"`
+}
+
+// DecodeResponse is the body of /decode responses
+type DecodeResponse struct {
+	Sentences []string `json:"sentences"`
+}
+
+// VocabToken is a token of the vocabulary together with its id
+type VocabToken struct {
+	ID    bpe.TokenID `json:"id"`
+	Token string      `json:"token"`
+}
+
+// VocabResponse is the body of /vocab responses
+type VocabResponse struct {
+	Tokens []VocabToken `json:"tokens"`
+}
+
+// ModelsResponse is the body of /models responses
+type ModelsResponse struct {
+	Models []string `json:"models"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func (s *Server) handleEncode(w http.ResponseWriter, r *http.Request) {
+	var request EncodeRequest
+	s.handleJSON(w, r, &request, func() (interface{}, error) {
+		file, err := s.model(request.Model)
+		if err != nil {
+			return nil, err
+		}
+		model := file.Model
+		encodingConfig := newEncodingConfig(file, request.BOS, request.EOS, request.Reverse,
+			request.Dropout, request.Seed)
+		switch request.OutputType {
+		case "", "id":
+			ids, err := model.EncodeSentences(request.Sentences, encodingConfig)
+			if err != nil {
+				return nil, badRequest(err)
+			}
+			return EncodeResponse{IDs: ids}, nil
+		case "subword":
+			subwords, err := model.EncodeSentencesToSubwords(request.Sentences, encodingConfig)
+			if err != nil {
+				return nil, badRequest(err)
+			}
+			return EncodeResponse{Subwords: subwords}, nil
+		default:
+			return nil, badRequest(fmt.Errorf("unknown output type %q", request.OutputType))
+		}
+	})
+}
+
+func (s *Server) handleDecode(w http.ResponseWriter, r *http.Request) {
+	var request DecodeRequest
+	s.handleJSON(w, r, &request, func() (interface{}, error) {
+		file, err := s.model(request.Model)
+		if err != nil {
+			return nil, err
+		}
+		sentences, err := file.Model.DecodeSentences(request.IDs, newDecodingConfig(
+			request.SkipSpecialTokens, request.StopAtEOS, request.Reverse))
+		if err != nil {
+			return nil, badRequest(err)
+		}
+		return DecodeResponse{sentences}, nil
+	})
+}
+
+func (s *Server) handleVocab(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	file, err := s.model(r.URL.Query().Get("model"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, VocabResponse{vocabTokens(file.Model)})
+}
+
+func (s *Server) handleModels(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	models := s.models()
+	names := make([]string, 0, len(models))
+	for name := range models {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	writeJSON(w, http.StatusOK, ModelsResponse{names})
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	if atomic.LoadInt32(&s.draining) != 0 {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "draining"})
+		return
+	}
+	if len(s.models()) == 0 {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "no models"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// model finds the model by name. The name may be empty if there is a single model.
+func (s *Server) model(name string) (bpe.ModelFile, error) {
+	models := s.models()
+	if name == "" {
+		if len(models) == 1 {
+			for _, model := range models {
+				return model, nil
+			}
+		}
+		return bpe.ModelFile{}, badRequest(errors.New("model is required"))
+	}
+	model, ok := models[name]
+	if !ok {
+		return bpe.ModelFile{}, &httpError{http.StatusNotFound,
+			fmt.Errorf("unknown model %q", name)}
+	}
+	return model, nil
+}
+
+// newEncodingConfig creates the configuration with the recorded settings of the model and
+// the options of the request
+func newEncodingConfig(file bpe.ModelFile, bos, eos, reverse bool, dropout float64,
+	seed *int64) bpe.EncodingConfig {
+	options := file.EncodingOptions()
+	if bos {
+		options = append(options, bpe.WithBOS())
+	}
+	if eos {
+		options = append(options, bpe.WithEOS())
+	}
+	if reverse {
+		options = append(options, bpe.Reversed())
+	}
+	if dropout != 0 {
+		var source rand.Source
+		if seed != nil {
+			source = rand.NewSource(*seed)
+		}
+		options = append(options, bpe.WithDropout(dropout, source))
+	}
+	return bpe.NewEncodingConfig(options...)
+}
+
+func newDecodingConfig(skipSpecialTokens, stopAtEOS, reverse bool) bpe.DecodingConfig {
+	var options []bpe.DecodingOption
+	if skipSpecialTokens {
+		options = append(options, bpe.SkipSpecialTokens())
+	}
+	if stopAtEOS {
+		options = append(options, bpe.StopAtEOS())
+	}
+	if reverse {
+		options = append(options, bpe.ReversedInput())
+	}
+	return bpe.NewDecodingConfig(options...)
+}
+
+func vocabTokens(model *bpe.Model) []VocabToken {
+	ids, vocab := model.VocabIDs(), model.Vocab()
+	tokens := make([]VocabToken, len(vocab))
+	for i, token := range vocab {
+		tokens[i] = VocabToken{ids[i], token}
+	}
+	return tokens
+}
+
+// errBodyTooLarge is returned by limitedBody when the body is longer than the limit
+var errBodyTooLarge = errors.New("request body too large")
+
+// limitedBody reads at most remaining bytes of the body and fails with errBodyTooLarge if there
+// are more
+type limitedBody struct {
+	body      io.Reader
+	remaining int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, errBodyTooLarge
+	}
+	// One more byte is read to tell the body of the maximal size from a longer one
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.body.Read(p)
+	if int64(n) > b.remaining {
+		n = int(b.remaining)
+		b.remaining = -1
+		return n, errBodyTooLarge
+	}
+	b.remaining -= int64(n)
+	return n, err
+}
+
+// handleJSON decodes the body of the POST request into request and writes the result of handle
+// as JSON
+func (s *Server) handleJSON(w http.ResponseWriter, r *http.Request, request interface{},
+	handle func() (interface{}, error)) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+	decoder := json.NewDecoder(&limitedBody{r.Body, s.maxBodyBytes})
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(request)
+	if err == nil {
+		// The limit applies to the trailing data too
+		if _, err = decoder.Token(); err == io.EOF {
+			err = nil
+		} else if !errors.Is(err, errBodyTooLarge) {
+			err = errors.New("trailing data")
+		}
+	}
+	if errors.Is(err, errBodyTooLarge) {
+		// The rest of the body is not read, so the connection cannot be reused
+		w.Header().Set("Connection", "close")
+		writeError(w, &httpError{http.StatusRequestEntityTooLarge, err})
+		return
+	}
+	if err != nil {
+		writeError(w, badRequest(fmt.Errorf("malformed request: %v", err)))
+		return
+	}
+	response, err := handle()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	writeError(w, &httpError{http.StatusMethodNotAllowed,
+		fmt.Errorf("method %s is not allowed", r.Method)})
+	return false
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var statusErr *httpError
+	if errors.As(err, &statusErr) {
+		status = statusErr.status
+	}
+	writeJSON(w, status, errorResponse{err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	// The status has been sent, a failure can only be caused by the client
+	_ = json.NewEncoder(w).Encode(body)
+}
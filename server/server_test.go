@@ -0,0 +1,228 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	bpe "github.com/src-d/go-YouTokenToMe"
+)
+
+func newTestServer(t *testing.T, options ...Option) *httptest.Server {
+	model, err := bpe.Train(strings.NewReader("aaab aab\nab"), 10, bpe.DefaultTrainOptions())
+	require.NoError(t, err)
+	other, err := bpe.Train(strings.NewReader("xyz xy"), 9, bpe.DefaultTrainOptions())
+	require.NoError(t, err)
+	return httptest.NewServer(New(map[string]*bpe.Model{"ab": model, "xy": other}, options...))
+}
+
+// request sends the request and decodes the JSON response into response. It returns the status.
+func request(t *testing.T, method, url, body string, response interface{}) int {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(response))
+	return resp.StatusCode
+}
+
+func TestServer_Encode(t *testing.T) {
+	req := require.New(t)
+	server := newTestServer(t)
+	defer server.Close()
+
+	var response EncodeResponse
+	status := request(t, http.MethodPost, server.URL+"/encode",
+		`{"model": "ab", "sentences": ["aab ab aaab", "ab", ""], "bos": true}`, &response)
+	req.Equal(http.StatusOK, status)
+	req.Equal([]bpe.EncodedString{{2, 9, 5, 6, 8, 9, 8}, {2, 6, 8}, {2}}, response.IDs)
+	req.Nil(response.Subwords)
+
+	response = EncodeResponse{}
+	status = request(t, http.MethodPost, server.URL+"/encode",
+		`{"model": "ab", "sentences": ["aab ab"], "eos": true, "reverse": true,
+		  "output_type": "subword"}`, &response)
+	req.Equal(http.StatusOK, status)
+	req.Equal([][]string{{"<EOS>", "ab", "▁", "b", "▁aa"}}, response.Subwords)
+
+	var first, second EncodeResponse
+	body := `{"model": "ab", "sentences": ["aab ab aaab aaab aab"], "dropout": 0.5, "seed": 7}`
+	req.Equal(http.StatusOK, request(t, http.MethodPost, server.URL+"/encode", body, &first))
+	req.Equal(http.StatusOK, request(t, http.MethodPost, server.URL+"/encode", body, &second))
+	req.Equal(first, second)
+
+	var errResponse errorResponse
+	for body, expected := range map[string]int{
+		`{"sentences": ["ab"]}`:                                    http.StatusBadRequest,
+		`{"model": "cd", "sentences": ["ab"]}`:                     http.StatusNotFound,
+		`{"model": "ab", "sentences": ["ab"], "dropout": 2}`:       http.StatusBadRequest,
+		`{"model": "ab", "sentences": ["ab"], "output_type": 1}`:   http.StatusBadRequest,
+		`{"model": "ab", "sentences": ["ab"], "output_type": "x"}`: http.StatusBadRequest,
+		`{"model": "ab", "sentence": "ab"}`:                        http.StatusBadRequest,
+		`{"model": "ab"} {}`:                                       http.StatusBadRequest,
+		`[`:                                                        http.StatusBadRequest,
+	} {
+		errResponse = errorResponse{}
+		req.Equal(expected, request(t, http.MethodPost, server.URL+"/encode", body, &errResponse),
+			body)
+		req.NotEmpty(errResponse.Error)
+	}
+	req.Equal(http.StatusMethodNotAllowed,
+		request(t, http.MethodGet, server.URL+"/encode", "", &errResponse))
+}
+
+func TestServer_EncodeBodyLimit(t *testing.T) {
+	req := require.New(t)
+	server := newTestServer(t, WithMaxBodyBytes(50))
+	defer server.Close()
+	var errResponse errorResponse
+	status := request(t, http.MethodPost, server.URL+"/encode",
+		`{"model": "ab", "sentences": ["`+strings.Repeat("ab ", 100)+`"]}`, &errResponse)
+	req.Equal(http.StatusRequestEntityTooLarge, status)
+
+	// The body of the maximal size is accepted
+	body := `{"model": "ab", "sentences": ["ab"]}`
+	server = newTestServer(t, WithMaxBodyBytes(int64(len(body))))
+	defer server.Close()
+	var response EncodeResponse
+	req.Equal(http.StatusOK, request(t, http.MethodPost, server.URL+"/encode", body, &response))
+	req.Equal(http.StatusRequestEntityTooLarge,
+		request(t, http.MethodPost, server.URL+"/encode", body+" ", &errResponse))
+}
+
+func TestLimitedBody(t *testing.T) {
+	req := require.New(t)
+	data, err := ioutil.ReadAll(&limitedBody{strings.NewReader("abc"), 3})
+	req.NoError(err)
+	req.Equal("abc", string(data))
+	data, err = ioutil.ReadAll(&limitedBody{strings.NewReader("abcd"), 3})
+	req.True(errors.Is(err, errBodyTooLarge))
+	req.Equal("abc", string(data))
+}
+
+func TestServer_Decode(t *testing.T) {
+	req := require.New(t)
+	server := newTestServer(t)
+	defer server.Close()
+
+	var response DecodeResponse
+	status := request(t, http.MethodPost, server.URL+"/decode",
+		`{"model": "ab", "ids": [[9, 5, 6, 8, 9, 8], [2, 9, 8, 3, 6, 4], []]}`, &response)
+	req.Equal(http.StatusOK, status)
+	req.Equal([]string{"aab ab aaab", "<BOS>aaab<EOS> a", ""}, response.Sentences)
+
+	status = request(t, http.MethodPost, server.URL+"/decode",
+		`{"model": "ab", "ids": [[3, 8, 9, 2, 1]], "reverse": true, "stop_at_eos": true,
+		  "skip_special_tokens": true}`, &response)
+	req.Equal(http.StatusOK, status)
+	req.Equal([]string{"aaab"}, response.Sentences)
+
+	var errResponse errorResponse
+	status = request(t, http.MethodPost, server.URL+"/decode",
+		`{"model": "ab", "ids": [[100]]}`, &errResponse)
+	req.Equal(http.StatusBadRequest, status)
+	req.Contains(errResponse.Error, "100")
+}
+
+func TestServer_Vocab(t *testing.T) {
+	req := require.New(t)
+	server := newTestServer(t)
+	defer server.Close()
+
+	var response VocabResponse
+	req.Equal(http.StatusOK, request(t, http.MethodGet, server.URL+"/vocab?model=xy", "",
+		&response))
+	req.Equal([]VocabToken{{0, "<PAD>"}, {1, "<UNK>"}, {2, "<BOS>"}, {3, "<EOS>"}, {4, "x"},
+		{5, "y"}, {6, "▁"}, {7, "z"}, {8, "xy"}}, response.Tokens)
+
+	var errResponse errorResponse
+	req.Equal(http.StatusBadRequest, request(t, http.MethodGet, server.URL+"/vocab", "",
+		&errResponse))
+	req.Equal("model is required", errResponse.Error)
+	req.Equal(http.StatusMethodNotAllowed, request(t, http.MethodPost, server.URL+"/vocab", "",
+		&errResponse))
+
+	var models ModelsResponse
+	req.Equal(http.StatusOK, request(t, http.MethodGet, server.URL+"/models", "", &models))
+	req.Equal([]string{"ab", "xy"}, models.Models)
+}
+
+func TestServer_SingleModel(t *testing.T) {
+	req := require.New(t)
+	model, err := bpe.Train(strings.NewReader("aaab aab\nab"), 10, bpe.DefaultTrainOptions())
+	req.NoError(err)
+	server := httptest.NewServer(New(map[string]*bpe.Model{"ab": model}))
+	defer server.Close()
+	var response EncodeResponse
+	req.Equal(http.StatusOK, request(t, http.MethodPost, server.URL+"/encode",
+		`{"sentences": ["ab"]}`, &response))
+	req.Equal([]bpe.EncodedString{{6, 8}}, response.IDs)
+}
+
+func TestNewWithRegistry(t *testing.T) {
+	req := require.New(t)
+	dir, err := ioutil.TempDir("", "server")
+	req.NoError(err)
+	defer os.RemoveAll(dir)
+	registry, err := bpe.NewRegistry(dir, bpe.WithPollInterval(0))
+	req.NoError(err)
+	server := httptest.NewServer(NewWithRegistry(registry))
+	defer server.Close()
+	var response map[string]string
+	req.Equal(http.StatusServiceUnavailable,
+		request(t, http.MethodGet, server.URL+"/readyz", "", &response))
+
+	model, err := bpe.Train(strings.NewReader("aaab aab\nab"), 10, bpe.DefaultTrainOptions())
+	req.NoError(err)
+	file, err := os.Create(filepath.Join(dir, "ab.yttm"))
+	req.NoError(err)
+	_, err = model.WriteTo(file)
+	req.NoError(err)
+	req.NoError(file.Close())
+	req.NoError(registry.Reload())
+	var encoded EncodeResponse
+	req.Equal(http.StatusOK, request(t, http.MethodPost, server.URL+"/encode",
+		`{"sentences": ["ab"]}`, &encoded))
+	req.Equal([]bpe.EncodedString{{6, 8}}, encoded.IDs)
+	var models ModelsResponse
+	req.Equal(http.StatusOK, request(t, http.MethodGet, server.URL+"/models", "", &models))
+	req.Equal([]string{"ab"}, models.Models)
+
+	// The recorded normalization is applied as soon as it is reloaded
+	req.NoError(bpe.WriteNormalization(filepath.Join(dir, "ab.yttm"),
+		bpe.Normalization{bpe.Lowercase}))
+	req.NoError(registry.Reload())
+	req.Equal(http.StatusOK, request(t, http.MethodPost, server.URL+"/encode",
+		`{"sentences": ["AB"]}`, &encoded))
+	req.Equal([]bpe.EncodedString{{6, 8}}, encoded.IDs)
+}
+
+func TestServer_Health(t *testing.T) {
+	req := require.New(t)
+	handler := New(map[string]*bpe.Model{"ab": {}})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	var response map[string]string
+	req.Equal(http.StatusOK, request(t, http.MethodGet, server.URL+"/healthz", "", &response))
+	req.Equal(http.StatusOK, request(t, http.MethodGet, server.URL+"/readyz", "", &response))
+	handler.Drain()
+	req.Equal(http.StatusServiceUnavailable,
+		request(t, http.MethodGet, server.URL+"/readyz", "", &response))
+	req.Equal("draining", response["status"])
+	req.Equal(http.StatusOK, request(t, http.MethodGet, server.URL+"/healthz", "", &response))
+
+	empty := httptest.NewServer(New(nil))
+	defer empty.Close()
+	req.Equal(http.StatusServiceUnavailable,
+		request(t, http.MethodGet, empty.URL+"/readyz", "", &response))
+}
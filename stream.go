@@ -0,0 +1,229 @@
+package bpe
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// lineReader splits a stream into lines the same way bufio.Scanner does by default but
+// does not limit the length of the lines unless maxLineLength is positive.
+type lineReader struct {
+	reader        *bufio.Reader
+	maxLineLength int
+	line          []byte
+	number        int
+	err           error
+}
+
+func newLineReader(reader io.Reader) *lineReader {
+	return &lineReader{reader: bufio.NewReader(reader)}
+}
+
+// next reads the next line, which is then available in lr.line. It returns false when
+// the stream is over or an error has occurred.
+func (lr *lineReader) next() bool {
+	if lr.err != nil {
+		return false
+	}
+	lr.line = lr.line[:0]
+	lr.number++
+	for {
+		chunk, err := lr.reader.ReadSlice('\n')
+		lr.line = append(lr.line, chunk...)
+		if lr.maxLineLength > 0 && len(bytes.TrimRight(lr.line, "\r\n")) > lr.maxLineLength {
+			lr.err = &LineError{lr.number, ErrLineTooLong}
+			return false
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err == io.EOF {
+			if len(lr.line) == 0 {
+				lr.err = io.EOF
+				return false
+			}
+			break
+		}
+		if err != nil {
+			lr.err = &LineError{lr.number, err}
+			return false
+		}
+		lr.line = lr.line[:len(lr.line)-1]
+		break
+	}
+	if len(lr.line) > 0 && lr.line[len(lr.line)-1] == '\r' {
+		lr.line = lr.line[:len(lr.line)-1]
+	}
+	return true
+}
+
+// error returns the first error which has occurred, io.EOF is not an error.
+func (lr *lineReader) error() error {
+	if lr.err == io.EOF {
+		return nil
+	}
+	return lr.err
+}
+
+// StreamEncoder reads sentences from a stream line by line and encodes them one at a time,
+// so that the whole stream is never kept in memory. It is used similar to bufio.Scanner:
+//
+//	encoder := model.NewStreamEncoder(reader, encodingConfig)
+//	for encoder.Next() {
+//		process(encoder.Encoded())
+//	}
+//	if err := encoder.Err(); err != nil {
+//		...
+//	}
+type StreamEncoder struct {
+	model          Model
+	lines          *lineReader
+	encodingConfig EncodingConfig
+	encoded        EncodedString
+	err            error
+}
+
+// NewStreamEncoder creates StreamEncoder which reads sentences from the reader and encodes them
+// with the given encodingConfig
+func (m Model) NewStreamEncoder(reader io.Reader, encodingConfig EncodingConfig) *StreamEncoder {
+	return &StreamEncoder{
+		model:          m,
+		lines:          newLineReader(reader),
+		encodingConfig: encodingConfig,
+	}
+}
+
+// Next encodes the next sentence, which is then available through Encoded. It returns false when
+// the stream is over or an error has occurred.
+func (se *StreamEncoder) Next() bool {
+	if se.err != nil {
+		return false
+	}
+	if !se.lines.next() {
+		se.err = se.lines.error()
+		se.encoded = nil
+		return false
+	}
+	se.encoded, se.err = se.model.EncodeSentence(string(se.lines.line), se.encodingConfig)
+	if se.err != nil {
+		se.err = &LineError{se.lines.number, se.err}
+		return false
+	}
+	return true
+}
+
+// SetMaxLineLength limits the length of the lines in bytes, longer lines make Next fail
+// with ErrLineTooLong. Lines are not limited by default. It must be called before the first
+// call to Next.
+func (se *StreamEncoder) SetMaxLineLength(maxLineLength int) {
+	se.lines.maxLineLength = maxLineLength
+}
+
+// Encoded returns the encoding of the sentence read by the last call to Next
+func (se *StreamEncoder) Encoded() EncodedString {
+	return se.encoded
+}
+
+// Err returns the first error which has occurred during the reading or the encoding.
+// The errors of the latter are *LineError.
+func (se *StreamEncoder) Err() error {
+	return se.err
+}
+
+// StreamDecoder reads encoded sentences from a stream line by line, token ids separated with
+// white space, and decodes them one at a time. It is used the same way as StreamEncoder.
+type StreamDecoder struct {
+	model          Model
+	lines          *lineReader
+	decodingConfig DecodingConfig
+	encoded        EncodedString
+	decoded        string
+	err            error
+}
+
+// NewStreamDecoder creates StreamDecoder which reads encoded sentences from the reader and
+// decodes them with the given decodingConfig
+func (m Model) NewStreamDecoder(reader io.Reader, decodingConfig DecodingConfig) *StreamDecoder {
+	return &StreamDecoder{
+		model:          m,
+		lines:          newLineReader(reader),
+		decodingConfig: decodingConfig,
+	}
+}
+
+// Next decodes the next sentence, which is then available through Decoded. It returns false when
+// the stream is over or an error has occurred.
+func (sd *StreamDecoder) Next() bool {
+	if sd.err != nil {
+		return false
+	}
+	sd.decoded = ""
+	if !sd.lines.next() {
+		sd.err = sd.lines.error()
+		return false
+	}
+	sd.encoded = sd.encoded[:0]
+	for _, number := range strings.Fields(string(sd.lines.line)) {
+		id, err := strconv.ParseUint(number, 10, 32)
+		if err != nil {
+			sd.err = &LineError{sd.lines.number, err}
+			return false
+		}
+		sd.encoded = append(sd.encoded, TokenID(id))
+	}
+	sd.decoded, sd.err = sd.model.DecodeSentence(sd.encoded, sd.decodingConfig)
+	if sd.err != nil {
+		sd.err = &LineError{sd.lines.number, sd.err}
+		return false
+	}
+	return true
+}
+
+// SetMaxLineLength limits the length of the lines in bytes, longer lines make Next fail
+// with ErrLineTooLong. Lines are not limited by default. It must be called before the first
+// call to Next.
+func (sd *StreamDecoder) SetMaxLineLength(maxLineLength int) {
+	sd.lines.maxLineLength = maxLineLength
+}
+
+// Decoded returns the sentence decoded by the last call to Next
+func (sd *StreamDecoder) Decoded() string {
+	return sd.decoded
+}
+
+// Err returns the first error which has occurred during the reading or the decoding.
+// The errors of the latter are *LineError.
+func (sd *StreamDecoder) Err() error {
+	return sd.err
+}
+
+// EncodeStreamTo reads sentences from the reader line by line and writes their encodings
+// to the writer: each sentence on its own line, token ids separated with spaces. This is
+// the format which DecodeFromStream reads.
+func (m Model) EncodeStreamTo(reader io.Reader, writer io.Writer,
+	encodingConfig EncodingConfig) error {
+	bufWriter := bufio.NewWriter(writer)
+	encoder := m.NewStreamEncoder(reader, encodingConfig)
+	var line []byte
+	for encoder.Next() {
+		line = line[:0]
+		for i, id := range encoder.Encoded() {
+			if i > 0 {
+				line = append(line, ' ')
+			}
+			line = strconv.AppendUint(line, uint64(id), 10)
+		}
+		line = append(line, '\n')
+		if _, err := bufWriter.Write(line); err != nil {
+			return err
+		}
+	}
+	if err := encoder.Err(); err != nil {
+		bufWriter.Flush()
+		return err
+	}
+	return bufWriter.Flush()
+}
@@ -0,0 +1,149 @@
+package bpe
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamEncoder(t *testing.T) {
+	req := require.New(t)
+	reader := strings.NewReader(`abcda bdhsab acad aaab baaaab
+
+gjhcbsd kbs;.jakjcdljk ajbabk,l kjaajlkj kj`)
+	encoder := BPE.NewStreamEncoder(reader, NewEncodingConfig(WithBOS(), WithEOS()))
+	var ids []EncodedString
+	for encoder.Next() {
+		ids = append(ids, encoder.Encoded())
+	}
+	req.NoError(encoder.Err())
+	req.Equal([]EncodedString{{2, 9, 7, 6, 5, 8, 12, 5, 1, 13, 9, 6, 8, 5, 9, 8, 13, 12, 14, 8, 13,
+		3}, {2, 3}, {2, 4, 1, 6, 7, 1, 5, 4, 1, 7, 1, 8, 1, 6, 5, 1, 9, 1, 7, 13, 1, 4, 1, 14, 1, 4,
+		1, 3}}, ids)
+	req.False(encoder.Next())
+	req.Nil(encoder.Encoded())
+
+	model := BPE
+	model.specialTokens.bos = -1
+	encoder = model.NewStreamEncoder(strings.NewReader("ab\nab"), NewEncodingConfig(WithBOS()))
+	req.False(encoder.Next())
+	req.Error(encoder.Err())
+	req.False(encoder.Next())
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestModel_EncodeStreamTo(t *testing.T) {
+	req := require.New(t)
+	var buf bytes.Buffer
+	err := BPE.EncodeStreamTo(strings.NewReader(`abcda bdhsab
+bdbca bbaacbd`), &buf, NewEncodingConfig(WithEOS()))
+	req.NoError(err)
+	req.Equal("9 7 6 5 8 12 5 1 13 3\n12 5 7 6 8 12 7 14 6 7 5 3\n", buf.String())
+
+	sentences, err := BPE.DecodeFromStream(&buf, NewDecodingConfig())
+	req.NoError(err)
+	req.Equal([]string{"abcda bd<UNK>ab<EOS>", "bdbca bbaacbd<EOS>"}, sentences)
+
+	err = BPE.EncodeStreamTo(strings.NewReader("ab"), failingWriter{}, NewEncodingConfig())
+	req.Error(err)
+}
+
+func TestLineReader(t *testing.T) {
+	req := require.New(t)
+	longLine := strings.Repeat("ab ", 100000)
+	lines := newLineReader(strings.NewReader("a\r\n\nb c\n" + longLine + "\nlast"))
+	var read []string
+	for lines.next() {
+		read = append(read, string(lines.line))
+	}
+	req.NoError(lines.error())
+	req.Equal([]string{"a", "", "b c", longLine, "last"}, read)
+	req.Equal(6, lines.number)
+	req.False(lines.next())
+
+	lines = newLineReader(strings.NewReader(""))
+	req.False(lines.next())
+	req.NoError(lines.error())
+
+	lines = newLineReader(strings.NewReader("ab\r\nabc\n"))
+	lines.maxLineLength = 2
+	req.True(lines.next())
+	req.False(lines.next())
+	req.Equal(&LineError{2, ErrLineTooLong}, lines.error())
+}
+
+func TestStreamEncoder_LongLines(t *testing.T) {
+	req := require.New(t)
+	longLine := strings.Repeat("abcd ", 100000)
+	encoder := BPE.NewStreamEncoder(strings.NewReader("ab\n"+longLine), NewEncodingConfig())
+	req.True(encoder.Next())
+	req.True(encoder.Next())
+	req.Len(encoder.Encoded(), 400000)
+	req.False(encoder.Next())
+	req.NoError(encoder.Err())
+
+	encoder = BPE.NewStreamEncoder(strings.NewReader("ab\n"+longLine), NewEncodingConfig())
+	encoder.SetMaxLineLength(1000)
+	req.True(encoder.Next())
+	req.False(encoder.Next())
+	req.Equal(&LineError{2, ErrLineTooLong}, encoder.Err())
+
+	model := BPE
+	model.specialTokens.eos = -1
+	encoder = model.NewStreamEncoder(strings.NewReader("ab\nab"), NewEncodingConfig(WithEOS()))
+	req.False(encoder.Next())
+	lineErr, ok := encoder.Err().(*LineError)
+	req.True(ok)
+	req.Equal(1, lineErr.Line)
+}
+
+func TestModel_DecodeFromStream_LongLines(t *testing.T) {
+	req := require.New(t)
+	longLine := strings.Repeat("9 7 6 5 ", 20000)
+	sentences, err := BPE.DecodeFromStream(strings.NewReader("9 7 6 5 9 7\n"+longLine),
+		NewDecodingConfig())
+	req.NoError(err)
+	req.Equal([]string{"abcd ab", strings.Repeat("abcd ", 20000)[:100000-1]}, sentences)
+
+	_, err = BPE.DecodeFromStream(strings.NewReader("9 7 6 5 9 7\n9 x"), NewDecodingConfig())
+	lineErr, ok := err.(*LineError)
+	req.True(ok)
+	req.Equal(2, lineErr.Line)
+
+	_, err = BPE.DecodeFromStream(strings.NewReader("9 7 6 5 9 7\n9 7 6 5 9 7\n9 30"),
+		NewDecodingConfig())
+	lineErr, ok = err.(*LineError)
+	req.True(ok)
+	req.Equal(3, lineErr.Line)
+}
+
+func TestStreamDecoder(t *testing.T) {
+	req := require.New(t)
+	decoder := BPE.NewStreamDecoder(strings.NewReader("2 9 7 6 5 3\n\n 12  5\r\n9 x\n9 7"),
+		NewDecodingConfig(SkipSpecialTokens()))
+	var sentences []string
+	for decoder.Next() {
+		sentences = append(sentences, decoder.Decoded())
+	}
+	req.Equal([]string{"abcd", "", "bd"}, sentences)
+	req.Equal("", decoder.Decoded())
+	lineErr, ok := decoder.Err().(*LineError)
+	req.True(ok)
+	req.Equal(4, lineErr.Line)
+	req.False(decoder.Next())
+
+	decoder = BPE.NewStreamDecoder(strings.NewReader("9 7\n9 7 6 5 9 7 6 5"), NewDecodingConfig())
+	decoder.SetMaxLineLength(10)
+	req.True(decoder.Next())
+	req.Equal("ab", decoder.Decoded())
+	req.False(decoder.Next())
+	req.Equal(&LineError{2, ErrLineTooLong}, decoder.Err())
+}
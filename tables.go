@@ -0,0 +1,96 @@
+package bpe
+
+// pairTable is an open-addressing hash table which maps pairs of token ids to the indices
+// of the rules. It takes much less memory than map[TokenIDPair]int and is faster to probe.
+type pairTable struct {
+	keys   []TokenIDPair
+	values []int32 // -1 marks the empty slots
+	size   int
+	shift  uint
+}
+
+func newPairTable(capacity int) pairTable {
+	nSlots, shift := 8, uint(61)
+	for nSlots < 2*capacity {
+		nSlots *= 2
+		shift--
+	}
+	pt := pairTable{
+		keys:   make([]TokenIDPair, nSlots),
+		values: make([]int32, nSlots),
+		shift:  shift,
+	}
+	for i := range pt.values {
+		pt.values[i] = -1
+	}
+	return pt
+}
+
+// slot returns the first slot to probe for the pair; Fibonacci hashing spreads the pairs of
+// consecutive ids evenly.
+func (pt pairTable) slot(pair TokenIDPair) int {
+	return int((uint64(pair) * 0x9E3779B97F4A7C15) >> pt.shift)
+}
+
+func (pt pairTable) get(pair TokenIDPair) (int, bool) {
+	if len(pt.keys) == 0 {
+		return 0, false
+	}
+	mask := len(pt.keys) - 1
+	for i := pt.slot(pair); ; i = (i + 1) & mask {
+		if pt.values[i] == -1 {
+			return 0, false
+		}
+		if pt.keys[i] == pair {
+			return int(pt.values[i]), true
+		}
+	}
+}
+
+func (pt *pairTable) put(pair TokenIDPair, value int) {
+	if 2*(pt.size+1) > len(pt.keys) {
+		pt.grow()
+	}
+	mask := len(pt.keys) - 1
+	for i := pt.slot(pair); ; i = (i + 1) & mask {
+		if pt.values[i] == -1 {
+			pt.keys[i] = pair
+			pt.values[i] = int32(value)
+			pt.size++
+			return
+		}
+		if pt.keys[i] == pair {
+			pt.values[i] = int32(value)
+			return
+		}
+	}
+}
+
+func (pt *pairTable) grow() {
+	grown := newPairTable(pt.size + 1)
+	for i, value := range pt.values {
+		if value != -1 {
+			grown.put(pt.keys[i], int(value))
+		}
+	}
+	*pt = grown
+}
+
+// len returns the number of pairs in the table
+func (pt pairTable) len() int {
+	return pt.size
+}
+
+// tokenEntry describes a token of the vocabulary. Chars have char set, merged tokens have
+// their text in Model.mergedText[start:end]. Ids which are not in the vocabulary have neither.
+type tokenEntry struct {
+	char  rune
+	start uint32
+	end   uint32
+}
+
+var absentToken = tokenEntry{-1, 0, 0}
+
+func (te tokenEntry) exists() bool {
+	return te.char != -1 || te.end != 0
+}
@@ -0,0 +1,55 @@
+package bpe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPairTable(t *testing.T) {
+	req := require.New(t)
+	var empty pairTable
+	_, ok := empty.get(newTokenIDPair(1, 2))
+	req.False(ok)
+
+	table := newPairTable(0)
+	req.Len(table.keys, 8)
+	expected := make(map[TokenIDPair]int)
+	for i := 0; i < 1000; i++ {
+		pair := newTokenIDPair(TokenID(i%37), TokenID(i))
+		table.put(pair, i)
+		expected[pair] = i
+	}
+	table.put(newTokenIDPair(0, 0), 5000)
+	expected[newTokenIDPair(0, 0)] = 5000
+	req.Equal(len(expected), table.len())
+	req.Len(table.keys, 2048)
+	for pair, value := range expected {
+		got, ok := table.get(pair)
+		req.True(ok)
+		req.Equal(value, got)
+	}
+	_, ok = table.get(newTokenIDPair(1, 2))
+	req.False(ok)
+	_, ok = table.get(newTokenIDPair(^TokenID(0), ^TokenID(0)))
+	req.False(ok)
+
+	table = newPairTable(100)
+	req.Len(table.keys, 256)
+}
+
+func TestTokenEntry_Exists(t *testing.T) {
+	req := require.New(t)
+	req.False(absentToken.exists())
+	req.True(tokenEntry{0, 0, 0}.exists())
+	req.True(tokenEntry{-1, 0, 3}.exists())
+}
+
+func TestModel_HasToken(t *testing.T) {
+	req := require.New(t)
+	req.False(BPE.hasToken(0))
+	req.True(BPE.hasToken(4))
+	req.True(BPE.hasToken(14))
+	req.False(BPE.hasToken(15))
+	req.False(BPE.hasToken(^TokenID(0)))
+}
@@ -0,0 +1,57 @@
+#!/usr/bin/env python3
+"""Generates the fixture which TestTrain_Upstream compares the training with.
+
+The model is trained by YouTokenToMe (pip install youtokentome) on corpus.txt with the default
+special tokens and full coverage, and every line of the corpus is encoded with it. YouTokenToMe
+saves its models as text, so the model is converted to the binary layout which ReadModel parses.
+
+    cd testdata/upstream && python3 generate.py
+"""
+
+import os
+import struct
+import tempfile
+
+import youtokentome as yttm
+
+VOCAB_SIZE = 120
+
+
+def convert(text_path, binary_path):
+    """Converts the text dump of YouTokenToMe: the line with the numbers of the chars and
+    the rules, a line with the code point and the id of every char, a line with the ids of
+    the operands and the result of every rule, and the line with the unk, pad, bos and eos ids.
+    The binary layout has the same values as big-endian 32-bit integers."""
+    with open(text_path, encoding="utf-8") as text:
+        lines = [list(map(int, line.split())) for line in text if line.strip()]
+    n_chars, n_rules = lines[0]
+    chars = lines[1:1 + n_chars]
+    rules = lines[1 + n_chars:1 + n_chars + n_rules]
+    specials = lines[1 + n_chars + n_rules]
+    assert all(len(char) == 2 for char in chars) and all(len(rule) == 3 for rule in rules)
+    assert len(specials) == 4
+    with open(binary_path, "wb") as binary:
+        binary.write(struct.pack(">II", n_chars, n_rules))
+        for char, char_id in chars:
+            binary.write(struct.pack(">II", char, char_id))
+        for left, right, result in rules:
+            binary.write(struct.pack(">III", left, right, result))
+        binary.write(struct.pack(">iiii", *specials))
+
+
+def main():
+    with tempfile.TemporaryDirectory() as tmp:
+        text_model = os.path.join(tmp, "model.txt")
+        yttm.BPE.train(data="corpus.txt", model=text_model, vocab_size=VOCAB_SIZE,
+                       coverage=1.0, n_threads=1, pad_id=0, unk_id=1, bos_id=2, eos_id=3)
+        bpe = yttm.BPE(text_model)
+        with open("corpus.txt", encoding="utf-8") as corpus:
+            lines = corpus.read().splitlines()
+        with open("encoded.txt", "w", encoding="utf-8") as encoded:
+            for ids in bpe.encode(lines, output_type=yttm.OutputType.ID):
+                encoded.write(" ".join(map(str, ids)) + "\n")
+        convert(text_model, "model.yttm")
+
+
+if __name__ == "__main__":
+    main()
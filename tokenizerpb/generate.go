@@ -0,0 +1,5 @@
+// Package tokenizerpb contains the protobuf messages and the gRPC service of the tokenizer,
+// the service is implemented in package server.
+package tokenizerpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative tokenizer.proto
@@ -0,0 +1,326 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package tokenizerpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// TokenizerClient is the client API for Tokenizer service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type TokenizerClient interface {
+	// Encode encodes a batch of sentences.
+	Encode(ctx context.Context, in *EncodeRequest, opts ...grpc.CallOption) (*EncodeResponse, error)
+	// Decode decodes a batch of encoded sentences.
+	Decode(ctx context.Context, in *DecodeRequest, opts ...grpc.CallOption) (*DecodeResponse, error)
+	// EncodeStream encodes text split into lines, like Model.EncodeStream. The client sends
+	// the text in chunks of any size and the server replies with an encoding per line as soon as
+	// the line is complete. The last line does not have to end with a line break.
+	EncodeStream(ctx context.Context, opts ...grpc.CallOption) (Tokenizer_EncodeStreamClient, error)
+	// DecodeStream decodes lines of token ids separated with white space,
+	// like Model.DecodeFromStream. It is streamed the same way as EncodeStream.
+	DecodeStream(ctx context.Context, opts ...grpc.CallOption) (Tokenizer_DecodeStreamClient, error)
+	// GetVocab returns the tokens of the model.
+	GetVocab(ctx context.Context, in *GetVocabRequest, opts ...grpc.CallOption) (*GetVocabResponse, error)
+}
+
+type tokenizerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTokenizerClient(cc grpc.ClientConnInterface) TokenizerClient {
+	return &tokenizerClient{cc}
+}
+
+func (c *tokenizerClient) Encode(ctx context.Context, in *EncodeRequest, opts ...grpc.CallOption) (*EncodeResponse, error) {
+	out := new(EncodeResponse)
+	err := c.cc.Invoke(ctx, "/yttm.v1.Tokenizer/Encode", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tokenizerClient) Decode(ctx context.Context, in *DecodeRequest, opts ...grpc.CallOption) (*DecodeResponse, error) {
+	out := new(DecodeResponse)
+	err := c.cc.Invoke(ctx, "/yttm.v1.Tokenizer/Decode", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tokenizerClient) EncodeStream(ctx context.Context, opts ...grpc.CallOption) (Tokenizer_EncodeStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Tokenizer_ServiceDesc.Streams[0], "/yttm.v1.Tokenizer/EncodeStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &tokenizerEncodeStreamClient{stream}
+	return x, nil
+}
+
+type Tokenizer_EncodeStreamClient interface {
+	Send(*EncodeStreamRequest) error
+	Recv() (*EncodeStreamResponse, error)
+	grpc.ClientStream
+}
+
+type tokenizerEncodeStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *tokenizerEncodeStreamClient) Send(m *EncodeStreamRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *tokenizerEncodeStreamClient) Recv() (*EncodeStreamResponse, error) {
+	m := new(EncodeStreamResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *tokenizerClient) DecodeStream(ctx context.Context, opts ...grpc.CallOption) (Tokenizer_DecodeStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Tokenizer_ServiceDesc.Streams[1], "/yttm.v1.Tokenizer/DecodeStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &tokenizerDecodeStreamClient{stream}
+	return x, nil
+}
+
+type Tokenizer_DecodeStreamClient interface {
+	Send(*DecodeStreamRequest) error
+	Recv() (*DecodeStreamResponse, error)
+	grpc.ClientStream
+}
+
+type tokenizerDecodeStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *tokenizerDecodeStreamClient) Send(m *DecodeStreamRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *tokenizerDecodeStreamClient) Recv() (*DecodeStreamResponse, error) {
+	m := new(DecodeStreamResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *tokenizerClient) GetVocab(ctx context.Context, in *GetVocabRequest, opts ...grpc.CallOption) (*GetVocabResponse, error) {
+	out := new(GetVocabResponse)
+	err := c.cc.Invoke(ctx, "/yttm.v1.Tokenizer/GetVocab", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TokenizerServer is the server API for Tokenizer service.
+// All implementations must embed UnimplementedTokenizerServer
+// for forward compatibility
+type TokenizerServer interface {
+	// Encode encodes a batch of sentences.
+	Encode(context.Context, *EncodeRequest) (*EncodeResponse, error)
+	// Decode decodes a batch of encoded sentences.
+	Decode(context.Context, *DecodeRequest) (*DecodeResponse, error)
+	// EncodeStream encodes text split into lines, like Model.EncodeStream. The client sends
+	// the text in chunks of any size and the server replies with an encoding per line as soon as
+	// the line is complete. The last line does not have to end with a line break.
+	EncodeStream(Tokenizer_EncodeStreamServer) error
+	// DecodeStream decodes lines of token ids separated with white space,
+	// like Model.DecodeFromStream. It is streamed the same way as EncodeStream.
+	DecodeStream(Tokenizer_DecodeStreamServer) error
+	// GetVocab returns the tokens of the model.
+	GetVocab(context.Context, *GetVocabRequest) (*GetVocabResponse, error)
+	mustEmbedUnimplementedTokenizerServer()
+}
+
+// UnimplementedTokenizerServer must be embedded to have forward compatible implementations.
+type UnimplementedTokenizerServer struct {
+}
+
+func (UnimplementedTokenizerServer) Encode(context.Context, *EncodeRequest) (*EncodeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Encode not implemented")
+}
+func (UnimplementedTokenizerServer) Decode(context.Context, *DecodeRequest) (*DecodeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Decode not implemented")
+}
+func (UnimplementedTokenizerServer) EncodeStream(Tokenizer_EncodeStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method EncodeStream not implemented")
+}
+func (UnimplementedTokenizerServer) DecodeStream(Tokenizer_DecodeStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method DecodeStream not implemented")
+}
+func (UnimplementedTokenizerServer) GetVocab(context.Context, *GetVocabRequest) (*GetVocabResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetVocab not implemented")
+}
+func (UnimplementedTokenizerServer) mustEmbedUnimplementedTokenizerServer() {}
+
+// UnsafeTokenizerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TokenizerServer will
+// result in compilation errors.
+type UnsafeTokenizerServer interface {
+	mustEmbedUnimplementedTokenizerServer()
+}
+
+func RegisterTokenizerServer(s grpc.ServiceRegistrar, srv TokenizerServer) {
+	s.RegisterService(&Tokenizer_ServiceDesc, srv)
+}
+
+func _Tokenizer_Encode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EncodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TokenizerServer).Encode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/yttm.v1.Tokenizer/Encode",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TokenizerServer).Encode(ctx, req.(*EncodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Tokenizer_Decode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DecodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TokenizerServer).Decode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/yttm.v1.Tokenizer/Decode",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TokenizerServer).Decode(ctx, req.(*DecodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Tokenizer_EncodeStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(TokenizerServer).EncodeStream(&tokenizerEncodeStreamServer{stream})
+}
+
+type Tokenizer_EncodeStreamServer interface {
+	Send(*EncodeStreamResponse) error
+	Recv() (*EncodeStreamRequest, error)
+	grpc.ServerStream
+}
+
+type tokenizerEncodeStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *tokenizerEncodeStreamServer) Send(m *EncodeStreamResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *tokenizerEncodeStreamServer) Recv() (*EncodeStreamRequest, error) {
+	m := new(EncodeStreamRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Tokenizer_DecodeStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(TokenizerServer).DecodeStream(&tokenizerDecodeStreamServer{stream})
+}
+
+type Tokenizer_DecodeStreamServer interface {
+	Send(*DecodeStreamResponse) error
+	Recv() (*DecodeStreamRequest, error)
+	grpc.ServerStream
+}
+
+type tokenizerDecodeStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *tokenizerDecodeStreamServer) Send(m *DecodeStreamResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *tokenizerDecodeStreamServer) Recv() (*DecodeStreamRequest, error) {
+	m := new(DecodeStreamRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Tokenizer_GetVocab_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetVocabRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TokenizerServer).GetVocab(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/yttm.v1.Tokenizer/GetVocab",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TokenizerServer).GetVocab(ctx, req.(*GetVocabRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Tokenizer_ServiceDesc is the grpc.ServiceDesc for Tokenizer service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Tokenizer_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "yttm.v1.Tokenizer",
+	HandlerType: (*TokenizerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Encode",
+			Handler:    _Tokenizer_Encode_Handler,
+		},
+		{
+			MethodName: "Decode",
+			Handler:    _Tokenizer_Decode_Handler,
+		},
+		{
+			MethodName: "GetVocab",
+			Handler:    _Tokenizer_GetVocab_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "EncodeStream",
+			Handler:       _Tokenizer_EncodeStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "DecodeStream",
+			Handler:       _Tokenizer_DecodeStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "tokenizer.proto",
+}
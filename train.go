@@ -0,0 +1,349 @@
+package bpe
+
+import (
+	"bufio"
+	"container/heap"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// spaceToken is the char which marks the beginning of every word in the models trained by Train.
+// It is the same char that upstream YouTokenToMe uses.
+const spaceToken = '▁'
+
+// unknownChar is the placeholder for the chars which were dropped by the character coverage.
+// Pairs which contain it are never merged.
+const unknownChar = ^TokenID(0)
+
+// TrainOptions is a configuration for training of BPE models
+type TrainOptions struct {
+	// CharacterCoverage is the fraction of char occurrences in the training text which must be
+	// covered by the vocabulary. The rarest chars beyond it are treated as unknown.
+	CharacterCoverage float64
+	// PadID, UnkID, BosID and EosID are the ids of the special tokens. -1 disables the token,
+	// UNK cannot be disabled. Enabled ids must be distinct and occupy the first ids of the
+	// vocabulary.
+	PadID int32
+	UnkID int32
+	BosID int32
+	EosID int32
+	// Normalizer is applied to the lines of the training text if it is set. The same normalizer
+	// must be passed to WithNormalizer for encoding; Normalization can be recorded with
+	// WriteNormalization.
+	Normalizer Normalizer
+	// PreTokenizer splits the lines into words, they are split on white space if it is not set.
+	// The same pre-tokenizer must be passed to WithPreTokenizer for encoding.
+	PreTokenizer PreTokenizer
+}
+
+// DefaultTrainOptions returns the training configuration which matches the defaults of
+// upstream YouTokenToMe
+func DefaultTrainOptions() TrainOptions {
+	return TrainOptions{
+		CharacterCoverage: 1,
+		PadID:             0,
+		UnkID:             1,
+		BosID:             2,
+		EosID:             3,
+	}
+}
+
+func (opts TrainOptions) specialTokens() (specialTokens, int, error) {
+	specials := specialTokens{unk: opts.UnkID, pad: opts.PadID, bos: opts.BosID, eos: opts.EosID}
+	if opts.CharacterCoverage <= 0 || opts.CharacterCoverage > 1 {
+		return specials, 0, fmt.Errorf("character coverage must be in (0, 1], got %v",
+			opts.CharacterCoverage)
+	}
+	if specials.unk < 0 {
+		return specials, 0, errors.New("unk token must be enabled")
+	}
+	var ids []int32
+	for _, id := range []int32{specials.unk, specials.pad, specials.bos, specials.eos} {
+		if id >= 0 {
+			ids = append(ids, id)
+		} else if id != -1 {
+			return specials, 0, fmt.Errorf("%d: special token id is impossible", id)
+		}
+	}
+	seen := make(map[int32]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] || int(id) >= len(ids) {
+			return specials, 0, errors.New("special token ids must be distinct and occupy " +
+				"the first ids of the vocabulary")
+		}
+		seen[id] = true
+	}
+	return specials, len(ids), nil
+}
+
+type charCount struct {
+	char  rune
+	count int64
+}
+
+// mergeCandidate is a pair of adjacent tokens together with the number of its occurrences
+// in the training text.
+type mergeCandidate struct {
+	count int64
+	left  TokenID
+	right TokenID
+}
+
+// less reports whether the candidate should be merged after the other one. Ties on the count
+// are resolved in favour of the pairs made of earlier tokens, the same way upstream does.
+func (mc mergeCandidate) less(other mergeCandidate) bool {
+	if mc.count != other.count {
+		return mc.count < other.count
+	}
+	thisMin, thisMax := mc.left, mc.right
+	if thisMin > thisMax {
+		thisMin, thisMax = thisMax, thisMin
+	}
+	otherMin, otherMax := other.left, other.right
+	if otherMin > otherMax {
+		otherMin, otherMax = otherMax, otherMin
+	}
+	if thisMax != otherMax {
+		return thisMax > otherMax
+	}
+	if thisMin != otherMin {
+		return thisMin > otherMin
+	}
+	return mc.left < other.left
+}
+
+type candidateQueue []mergeCandidate
+
+func (cq candidateQueue) Len() int { return len(cq) }
+
+func (cq candidateQueue) Less(i, j int) bool { return cq[j].less(cq[i]) }
+
+func (cq candidateQueue) Swap(i, j int) {
+	cq[i], cq[j] = cq[j], cq[i]
+}
+
+func (cq *candidateQueue) Push(x interface{}) {
+	*cq = append(*cq, x.(mergeCandidate))
+}
+
+func (cq *candidateQueue) Pop() interface{} {
+	old := *cq
+	n := len(old)
+	item := old[n-1]
+	*cq = old[0 : n-1]
+	return item
+}
+
+// trainingWord is a distinct word of the training text split into the current tokens.
+type trainingWord struct {
+	tokens []TokenID
+	count  int64
+}
+
+// pairStats keeps the number of occurrences of every adjacent pair of tokens and the words
+// where the pair may occur.
+type pairStats struct {
+	counts  map[TokenIDPair]int64
+	where   map[TokenIDPair]map[int]struct{}
+	changed map[TokenIDPair]struct{}
+}
+
+func (ps *pairStats) update(word *trainingWord, index int, sign int64) {
+	for i := 0; i+1 < len(word.tokens); i++ {
+		left, right := word.tokens[i], word.tokens[i+1]
+		if left == unknownChar || right == unknownChar {
+			continue
+		}
+		pair := newTokenIDPair(left, right)
+		ps.counts[pair] += sign * word.count
+		if ps.counts[pair] == 0 {
+			delete(ps.counts, pair)
+		}
+		if sign > 0 {
+			if _, ok := ps.where[pair]; !ok {
+				ps.where[pair] = make(map[int]struct{})
+			}
+			ps.where[pair][index] = struct{}{}
+		}
+		ps.changed[pair] = struct{}{}
+	}
+}
+
+// Train learns a BPE model with vocabSize tokens (special tokens included) from the text read
+// from the reader. The text is split into words on white space or with opts.PreTokenizer,
+// the chars beyond opts.CharacterCoverage are dropped and then the most frequent pair of adjacent
+// tokens is merged into a new token until the vocabulary is full or there is nothing left
+// to merge.
+func Train(reader io.Reader, vocabSize int, opts TrainOptions) (*Model, error) {
+	specials, nSpecials, err := opts.specialTokens()
+	if err != nil {
+		return &Model{}, err
+	}
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(nil, 1<<30)
+	wordCounts := make(map[wordKey]int64)
+	if opts.Normalizer == nil && opts.PreTokenizer == nil {
+		scanner.Split(bufio.ScanWords)
+		for scanner.Scan() {
+			wordCounts[wordKey{word: scanner.Text()}]++
+		}
+	} else {
+		// The lines are processed the same way EncodeSentence processes the sentences
+		encodingConfig := NewEncodingConfig(WithPreTokenizer(opts.PreTokenizer))
+		for scanner.Scan() {
+			line := scanner.Text()
+			if opts.Normalizer != nil {
+				line = opts.Normalizer.Normalize(line)
+			}
+			start, end, err := encodingConfig.nextWord(line, 0)
+			for err == nil && start != -1 {
+				key := wordKey{line[start:end], !followsSpace(line, start)}
+				if _, ok := wordCounts[key]; !ok {
+					// The word must not keep the whole line in memory
+					key.word = string([]byte(key.word))
+				}
+				wordCounts[key]++
+				start, end, err = encodingConfig.nextWord(line, end)
+			}
+			if err != nil {
+				return &Model{}, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return &Model{}, err
+	}
+
+	// Count chars and drop the rarest ones which are beyond the character coverage
+	counts := make(map[rune]int64)
+	var total int64
+	for key, count := range wordCounts {
+		for _, char := range key.word {
+			counts[char] += count
+			total += count
+		}
+		if !key.attached {
+			// The space token in front of the word
+			counts[spaceToken] += count
+			total += count
+		}
+	}
+	space := charCount{spaceToken, counts[spaceToken]}
+	delete(counts, spaceToken)
+	chars := make([]charCount, 0, len(counts)+1)
+	for char, count := range counts {
+		chars = append(chars, charCount{char, count})
+	}
+	sort.Slice(chars, func(i, j int) bool {
+		return chars[i].count > chars[j].count ||
+			chars[i].count == chars[j].count && chars[i].char < chars[j].char
+	})
+	maxSkipped := int64((1 - opts.CharacterCoverage) * float64(total))
+	var skipped int64
+	for len(chars) > 0 && skipped+chars[len(chars)-1].count <= maxSkipped {
+		skipped += chars[len(chars)-1].count
+		chars = chars[:len(chars)-1]
+	}
+	// The space token is never dropped but it gets its id by its frequency like the chars do
+	rank := sort.Search(len(chars), func(i int) bool {
+		return chars[i].count < space.count ||
+			chars[i].count == space.count && chars[i].char > space.char
+	})
+	chars = append(chars, charCount{})
+	copy(chars[rank+1:], chars[rank:])
+	chars[rank] = space
+	nRules := vocabSize - nSpecials - len(chars)
+	if nRules < 0 {
+		return &Model{}, fmt.Errorf("vocabulary size is too small: at least %d tokens required",
+			nSpecials+len(chars))
+	}
+	char2id := make(map[rune]TokenID, len(chars))
+	for i, char := range chars {
+		char2id[char.char] = TokenID(nSpecials + i)
+	}
+
+	// Split the words into chars and collect the statistics of adjacent pairs
+	words := make([]trainingWord, 0, len(wordCounts))
+	for key, count := range wordCounts {
+		var tokens []TokenID
+		if !key.attached {
+			tokens = append(tokens, char2id[spaceToken])
+		}
+		for _, char := range key.word {
+			if charID, ok := char2id[char]; ok {
+				tokens = append(tokens, charID)
+			} else {
+				tokens = append(tokens, unknownChar)
+			}
+		}
+		words = append(words, trainingWord{tokens, count})
+	}
+	stats := pairStats{
+		make(map[TokenIDPair]int64),
+		make(map[TokenIDPair]map[int]struct{}),
+		make(map[TokenIDPair]struct{}),
+	}
+	for i := range words {
+		stats.update(&words[i], i, 1)
+	}
+	var candidates candidateQueue
+	for pair, count := range stats.counts {
+		candidates = append(candidates, mergeCandidate{count, TokenID(pair >> 32), TokenID(pair)})
+	}
+	heap.Init(&candidates)
+
+	// Merge the most frequent pairs one by one
+	rules := make([]rule, 0, nRules)
+	nextID := TokenID(nSpecials + len(chars))
+	for len(rules) < nRules && len(candidates) > 0 {
+		candidate := heap.Pop(&candidates).(mergeCandidate)
+		pair := newTokenIDPair(candidate.left, candidate.right)
+		if stats.counts[pair] != candidate.count {
+			// The count has changed since the candidate was pushed
+			continue
+		}
+		merged := rule{candidate.left, candidate.right, nextID}
+		rules = append(rules, merged)
+		nextID++
+		stats.changed = make(map[TokenIDPair]struct{})
+		indices := stats.where[pair]
+		delete(stats.where, pair)
+		for i := range indices {
+			word := &words[i]
+			stats.update(word, i, -1)
+			tokens := word.tokens[:0]
+			for pos := 0; pos < len(word.tokens); pos++ {
+				if pos+1 < len(word.tokens) && word.tokens[pos] == merged.left &&
+					word.tokens[pos+1] == merged.right {
+					tokens = append(tokens, merged.result)
+					pos++
+				} else {
+					tokens = append(tokens, word.tokens[pos])
+				}
+			}
+			word.tokens = tokens
+			stats.update(word, i, 1)
+		}
+		for changed := range stats.changed {
+			if count, ok := stats.counts[changed]; ok {
+				heap.Push(&candidates,
+					mergeCandidate{count, TokenID(changed >> 32), TokenID(changed)})
+			}
+		}
+	}
+
+	model := newModel(len(rules))
+	for char, charID := range char2id {
+		model.addChar(char, charID)
+	}
+	model.spaceID = char2id[spaceToken]
+	for i, r := range rules {
+		if err := model.addRule(i, r); err != nil {
+			return model, err
+		}
+	}
+	model.setSpecialTokens(specials)
+	return model, nil
+}
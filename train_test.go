@@ -0,0 +1,143 @@
+package bpe
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrainOptions_SpecialTokens(t *testing.T) {
+	req := require.New(t)
+	specials, n, err := DefaultTrainOptions().specialTokens()
+	req.NoError(err)
+	req.Equal(specialTokens{1, 0, 2, 3}, specials)
+	req.Equal(4, n)
+
+	specials, n, err = TrainOptions{1, -1, 0, -1, 1, nil, nil}.specialTokens()
+	req.NoError(err)
+	req.Equal(specialTokens{0, -1, -1, 1}, specials)
+	req.Equal(2, n)
+
+	_, _, err = TrainOptions{1, 0, -1, 1, 2, nil, nil}.specialTokens()
+	req.Error(err)
+	_, _, err = TrainOptions{1, 0, 1, 1, 2, nil, nil}.specialTokens()
+	req.Error(err)
+	_, _, err = TrainOptions{1, 0, 1, 2, 5, nil, nil}.specialTokens()
+	req.Error(err)
+	_, _, err = TrainOptions{0, 0, 1, 2, 3, nil, nil}.specialTokens()
+	req.Error(err)
+}
+
+func TestMergeCandidate_Less(t *testing.T) {
+	req := require.New(t)
+	req.True(mergeCandidate{2, 4, 5}.less(mergeCandidate{3, 7, 8}))
+	req.True(mergeCandidate{3, 5, 6}.less(mergeCandidate{3, 4, 5}))
+	req.True(mergeCandidate{3, 5, 5}.less(mergeCandidate{3, 4, 5}))
+	req.True(mergeCandidate{3, 4, 5}.less(mergeCandidate{3, 5, 4}))
+	req.False(mergeCandidate{3, 4, 5}.less(mergeCandidate{3, 4, 5}))
+}
+
+func TestTrain(t *testing.T) {
+	req := require.New(t)
+	model, err := Train(strings.NewReader("aaab aab\nab"), 10, DefaultTrainOptions())
+	req.NoError(err)
+	// The space token is as frequent as "b" and goes after it
+	req.Equal(map[rune]TokenID{'a': 4, 'b': 5, spaceToken: 6}, model.char2id)
+	req.Equal(TokenID(6), model.spaceID)
+	req.Equal([]rule{{4, 4, 7}, {4, 5, 8}, {6, 7, 9}}, model.rules)
+	req.Equal(specialTokens{1, 0, 2, 3}, model.specialTokens)
+	req.Equal(TokenID(9), model.revRecipe["▁aa"])
+	req.Equal(TokenID(8), model.revRecipe["ab"])
+
+	ids, err := model.EncodeSentence("aab ab aaab", EncodingConfig{})
+	req.NoError(err)
+	req.Equal(EncodedString{9, 5, 6, 8, 9, 8}, ids)
+	sentence, err := model.DecodeSentence(ids, NewDecodingConfig())
+	req.NoError(err)
+	req.Equal("aab ab aaab", sentence)
+
+	var buf bytes.Buffer
+	_, err = model.WriteTo(&buf)
+	req.NoError(err)
+	restored, err := ReadModel(&buf)
+	req.NoError(err)
+	req.Equal(model, restored)
+
+	// The space token in the text is counted together with the ones in front of the words
+	model, err = Train(strings.NewReader("a b c ▁a"), 8, DefaultTrainOptions())
+	req.NoError(err)
+	req.Equal(map[rune]TokenID{spaceToken: 4, 'a': 5, 'b': 6, 'c': 7}, model.char2id)
+	model, err = Train(strings.NewReader("aaa bbb c"), 8, DefaultTrainOptions())
+	req.NoError(err)
+	req.Equal(map[rune]TokenID{'a': 4, 'b': 5, spaceToken: 6, 'c': 7}, model.char2id)
+
+	// No more pairs to merge
+	model, err = Train(strings.NewReader("aaab aab\nab"), 100, DefaultTrainOptions())
+	req.NoError(err)
+	req.Len(model.rules, 6)
+
+	_, err = Train(strings.NewReader("aaab aab\nab"), 6, DefaultTrainOptions())
+	req.Error(err)
+	_, err = Train(strings.NewReader("aaab aab\nab"), 10, TrainOptions{})
+	req.Error(err)
+}
+
+func TestTrain_Upstream(t *testing.T) {
+	req := require.New(t)
+	// The fixture is generated by testdata/upstream/generate.py with YouTokenToMe
+	dir := filepath.Join("testdata", "upstream")
+	file, err := os.Open(filepath.Join(dir, "model.yttm"))
+	req.NoError(err, "run testdata/upstream/generate.py to generate the upstream model")
+	defer file.Close()
+	expected, err := ReadModel(file)
+	req.NoError(err)
+	corpus, err := ioutil.ReadFile(filepath.Join(dir, "corpus.txt"))
+	req.NoError(err)
+	encoded, err := ioutil.ReadFile(filepath.Join(dir, "encoded.txt"))
+	req.NoError(err)
+
+	model, err := Train(bytes.NewReader(corpus), len(expected.Vocab()), DefaultTrainOptions())
+	req.NoError(err)
+	req.Equal(expected.char2id, model.char2id)
+	req.Equal(expected.spaceID, model.spaceID)
+	req.Equal(expected.rules, model.rules)
+	req.Equal(expected.Vocab(), model.Vocab())
+
+	sentences := strings.Split(strings.TrimSuffix(string(corpus), "\n"), "\n")
+	lines := strings.Split(strings.TrimSuffix(string(encoded), "\n"), "\n")
+	req.Len(lines, len(sentences))
+	for i, sentence := range sentences {
+		var expectedIDs EncodedString
+		for _, field := range strings.Fields(lines[i]) {
+			id, err := strconv.ParseUint(field, 10, 32)
+			req.NoError(err)
+			expectedIDs = append(expectedIDs, TokenID(id))
+		}
+		ids, err := model.EncodeSentence(sentence, NewEncodingConfig())
+		req.NoError(err)
+		req.Equal(expectedIDs, ids, sentence)
+	}
+}
+
+func TestTrain_CharacterCoverage(t *testing.T) {
+	req := require.New(t)
+	opts := DefaultTrainOptions()
+	opts.CharacterCoverage = 0.8
+	model, err := Train(strings.NewReader("abcab abab cab abab"), 10, opts)
+	req.NoError(err)
+	_, ok := model.char2id['c']
+	req.False(ok)
+	req.Len(model.char2id, 3)
+
+	ids, err := model.EncodeSentence("abcab", EncodingConfig{})
+	req.NoError(err)
+	sentence, err := model.DecodeSentence(ids, NewDecodingConfig())
+	req.NoError(err)
+	req.Equal("ab<UNK>ab", sentence)
+}
@@ -0,0 +1,164 @@
+package bpe
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"unicode/utf8"
+)
+
+// Problem is an inconsistency of the model found by Validate or ReadModelStrict
+type Problem struct {
+	// Offset is the position of the wrong field in the binary dump, -1 if the problem does not
+	// belong to a single field. The offsets of Validate refer to the dump written by WriteTo.
+	Offset      int64
+	Description string
+}
+
+func (p Problem) String() string {
+	if p.Offset < 0 {
+		return p.Description
+	}
+	return fmt.Sprintf("byte %d: %s", p.Offset, p.Description)
+}
+
+// Validate checks the model for the inconsistencies which make the encoding ambiguous or
+// the decoding fail: chars or ids which are defined twice, rules which merge the same pair or
+// use the tokens defined after them, rule results which collide with other tokens, special
+// tokens outside of the vocabulary. It returns nil if the model is consistent.
+func (m Model) Validate() []Problem {
+	var dump modelDump
+	for id, token := range m.tokens {
+		if token.char != -1 {
+			dump.chars = append(dump.chars, dumpChar{token.char, TokenID(id)})
+		}
+	}
+	dump.rules = m.rules
+	dump.specials = m.specialTokens
+	problems := dump.validate()
+	// The tables of the model keep a single token per id, the overwritten ones are only
+	// noticeable by the chars which point to another token
+	for char, id := range m.char2id {
+		if !m.hasToken(id) || m.tokens[id].char != char {
+			problems = append(problems, Problem{-1,
+				fmt.Sprintf("id %d of char %q is taken by another token", id, char)})
+		}
+	}
+	if len(m.char2id) > 0 && (!m.hasToken(m.spaceID) || m.tokens[m.spaceID].char == -1) {
+		problems = append(problems, Problem{-1,
+			fmt.Sprintf("space id %d does not belong to a char", m.spaceID)})
+	}
+	return problems
+}
+
+// ReadModelStrict works like ReadModel but rejects the dump if Validate would find any problem
+// in it or if anything follows the special tokens. Unlike ReadModel, it reports all the problems
+// at once as *InvalidModelError, together with their offsets in the dump. If the dump ends
+// prematurely, the error is *TruncatedModelError.
+func ReadModelStrict(reader io.Reader) (*Model, error) {
+	dump, err := readModelDump(reader)
+	if err != nil {
+		return &Model{}, err
+	}
+	problems := dump.validate()
+	trailing, err := io.Copy(ioutil.Discard, reader)
+	if err != nil {
+		logger.Errorf("Broken input: %v", err)
+		return &Model{}, err
+	}
+	if trailing > 0 {
+		problems = append(problems, Problem{dump.size(),
+			fmt.Sprintf("%d bytes of trailing garbage", trailing)})
+	}
+	if len(problems) > 0 {
+		err := &InvalidModelError{problems}
+		logger.Errorf("%v", err)
+		return &Model{}, err
+	}
+	return dump.build()
+}
+
+// size returns the length of the binary dump in bytes
+func (dump modelDump) size() int64 {
+	return 8 + 8*int64(len(dump.chars)) + 12*int64(len(dump.rules)) + 16
+}
+
+// validate finds the problems of the dump in the order of their offsets
+func (dump modelDump) validate() []Problem {
+	var problems []Problem
+	report := func(offset int64, format string, args ...interface{}) {
+		problems = append(problems, Problem{offset, fmt.Sprintf(format, args...)})
+	}
+	specials := []struct {
+		name string
+		id   int32
+	}{
+		{unkToken, dump.specials.unk},
+		{padToken, dump.specials.pad},
+		{bosToken, dump.specials.bos},
+		{eosToken, dump.specials.eos},
+	}
+	vocabSize := len(dump.chars) + len(dump.rules)
+	for _, special := range specials {
+		if special.id >= 0 {
+			vocabSize++
+		}
+	}
+	// owners describes the tokens which have taken the ids so far
+	owners := make(map[TokenID]string, vocabSize)
+	checkID := func(offset int64, id TokenID, owner string) {
+		if int64(id) >= int64(vocabSize) {
+			report(offset, "id %d of %s is outside of the vocabulary of %d tokens", id, owner,
+				vocabSize)
+		} else if other, ok := owners[id]; ok {
+			report(offset, "id %d of %s is already taken by %s", id, owner, other)
+			return
+		}
+		owners[id] = owner
+	}
+
+	offset := int64(8)
+	seenChars := make(map[rune]int, len(dump.chars))
+	for i, char := range dump.chars {
+		owner := fmt.Sprintf("char %q", char.char)
+		if !utf8.ValidRune(char.char) {
+			report(offset, "char %d is not a valid code point", char.char)
+		} else if other, ok := seenChars[char.char]; ok {
+			report(offset, "char %q is defined twice, first as char %d", char.char, other)
+		} else {
+			seenChars[char.char] = i
+		}
+		checkID(offset+4, char.id, owner)
+		offset += 8
+	}
+	seenPairs := make(map[TokenIDPair]int, len(dump.rules))
+	for i, r := range dump.rules {
+		owner := fmt.Sprintf("rule %d", i)
+		if _, ok := owners[r.left]; !ok {
+			report(offset, "left token %d of rule %d is not defined before it", r.left, i)
+		}
+		if _, ok := owners[r.right]; !ok {
+			report(offset+4, "right token %d of rule %d is not defined before it", r.right, i)
+		}
+		pair := newTokenIDPair(r.left, r.right)
+		if other, ok := seenPairs[pair]; ok {
+			report(offset, "rule %d merges the same pair as rule %d", i, other)
+		} else {
+			seenPairs[pair] = i
+		}
+		checkID(offset+8, r.result, owner)
+		offset += 12
+	}
+	for _, special := range specials {
+		switch {
+		case special.id == -1 && special.name == unkToken:
+			report(offset, "%s token is disabled", special.name)
+		case special.id < -1:
+			report(offset, "id %d of %s is impossible", special.id, special.name)
+		case special.id >= 0:
+			checkID(offset, TokenID(special.id), special.name)
+		}
+		offset += 4
+	}
+	return problems
+}
@@ -0,0 +1,98 @@
+package bpe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func dumpToBinary(dump modelDump) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint32(buf, uint32(len(dump.chars)))
+	binary.BigEndian.PutUint32(buf[4:], uint32(len(dump.rules)))
+	for _, char := range dump.chars {
+		buf = append(buf, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(buf[len(buf)-8:], uint32(char.char))
+		binary.BigEndian.PutUint32(buf[len(buf)-4:], uint32(char.id))
+	}
+	for _, r := range dump.rules {
+		buf = append(buf, r.toBinary()...)
+	}
+	return append(buf, dump.specials.toBinary()...)
+}
+
+func TestModel_Validate(t *testing.T) {
+	req := require.New(t)
+	req.Nil(BPE.Validate())
+	model, err := Train(strings.NewReader("aaab aab\nab"), 10, DefaultTrainOptions())
+	req.NoError(err)
+	req.Nil(model.Validate())
+
+	// The rule overwrites the char in the tables of the model
+	model, err = ReadModel(bytes.NewReader(dumpToBinary(modelDump{
+		[]dumpChar{{'a', 4}, {'b', 5}},
+		[]rule{{4, 5, 6}, {4, 6, 5}},
+		specialTokens{1, 0, 2, 3},
+	})))
+	req.NoError(err)
+	req.Equal([]Problem{
+		{20, "right token 5 of rule 0 is not defined before it"},
+		{-1, "id 5 of char 'b' is taken by another token"},
+	}, model.Validate())
+
+	broken := BPE
+	broken.spaceID = 9
+	req.Equal([]Problem{{-1, "space id 9 does not belong to a char"}}, broken.Validate())
+
+	model = newModel(0)
+	req.Equal([]Problem{{8, "<UNK> token is disabled"}}, model.Validate())
+}
+
+func TestReadModelStrict(t *testing.T) {
+	req := require.New(t)
+	var buf bytes.Buffer
+	_, err := BPE.WriteTo(&buf)
+	req.NoError(err)
+	model, err := ReadModelStrict(bytes.NewReader(buf.Bytes()))
+	req.NoError(err)
+	req.Equal(BPE, *model)
+
+	_, err = ReadModelStrict(bytes.NewReader(buf.Bytes()[:20]))
+	req.True(errors.Is(err, ErrTruncatedModel))
+
+	broken := append(dumpToBinary(modelDump{
+		[]dumpChar{{'a', 4}, {'b', 4}, {'a', 5}},
+		[]rule{{4, 5, 6}, {4, 5, 7}, {6, 8, 4}},
+		specialTokens{1, 0, 9, -1},
+	}), 1, 2, 3)
+	_, err = ReadModelStrict(bytes.NewReader(broken))
+	req.True(errors.Is(err, ErrInvalidModel))
+	var invalidErr *InvalidModelError
+	req.True(errors.As(err, &invalidErr))
+	req.Equal([]Problem{
+		{20, "id 4 of char 'b' is already taken by char 'a'"},
+		{24, "char 'a' is defined twice, first as char 0"},
+		{44, "rule 1 merges the same pair as rule 0"},
+		{60, "right token 8 of rule 2 is not defined before it"},
+		{64, "id 4 of rule 2 is already taken by char 'a'"},
+		{76, "id 9 of <BOS> is outside of the vocabulary of 9 tokens"},
+		{84, "3 bytes of trailing garbage"},
+	}, invalidErr.Problems)
+	req.Contains(err.Error(), "byte 84: 3 bytes of trailing garbage")
+
+	_, err = ReadModelStrict(bytes.NewReader(dumpToBinary(modelDump{
+		[]dumpChar{{-5, 0}, {'a', 1}},
+		[]rule{{1, 1, 2}},
+		specialTokens{3, 3, -7, -1},
+	})))
+	req.True(errors.As(err, &invalidErr))
+	req.Equal([]Problem{
+		{8, "char -5 is not a valid code point"},
+		{40, "id 3 of <PAD> is already taken by <UNK>"},
+		{44, "id -7 of <BOS> is impossible"},
+	}, invalidErr.Problems)
+}
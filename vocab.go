@@ -0,0 +1,104 @@
+package bpe
+
+import "sort"
+
+// VocabSize returns the number of tokens in the vocabulary of the model, special tokens included
+func (m Model) VocabSize() int {
+	size := 0
+	for _, token := range m.tokens {
+		if token.exists() {
+			size++
+		}
+	}
+	for _, id := range []int32{m.specialTokens.unk, m.specialTokens.pad, m.specialTokens.bos,
+		m.specialTokens.eos} {
+		if id != -1 {
+			size++
+		}
+	}
+	return size
+}
+
+// Vocab returns all the tokens of the model ordered by their ids. Subwords which start words
+// keep the special space char at the beginning.
+func (m Model) Vocab() []string {
+	ids := m.VocabIDs()
+	vocab := make([]string, len(ids))
+	for i, id := range ids {
+		// All the ids are known, so there cannot be an error
+		vocab[i], _ = m.IDToToken(id, false)
+	}
+	return vocab
+}
+
+// VocabIDs returns the ids of all the tokens of the model in ascending order, so that the i-th
+// id belongs to the i-th token of Vocab. The ids are not always consecutive, e.g. when some of
+// the special tokens are disabled.
+func (m Model) VocabIDs() []TokenID {
+	ids := make([]TokenID, 0, m.VocabSize())
+	for id, token := range m.tokens {
+		if token.exists() {
+			ids = append(ids, TokenID(id))
+		}
+	}
+	for _, id := range []int32{m.specialTokens.unk, m.specialTokens.pad, m.specialTokens.bos,
+		m.specialTokens.eos} {
+		if id != -1 {
+			ids = append(ids, TokenID(id))
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// TokenToID returns the id of the given token. The second value is false if the token is not
+// in the vocabulary.
+func (m Model) TokenToID(token string) (TokenID, bool) {
+	switch token {
+	case unkToken:
+		return m.UnkID()
+	case padToken:
+		return m.PadID()
+	case bosToken:
+		return m.BosID()
+	case eosToken:
+		return m.EosID()
+	}
+	if m.revRecipe == nil {
+		return m.searchTextIndex(token)
+	}
+	id, ok := m.revRecipe[token]
+	return id, ok
+}
+
+// searchTextIndex finds the token in the ids sorted by their texts
+func (m Model) searchTextIndex(token string) (TokenID, bool) {
+	var buf [64]byte
+	i := sort.Search(len(m.textIndex), func(i int) bool {
+		return string(m.appendToken(buf[:0], m.textIndex[i])) >= token
+	})
+	if i < len(m.textIndex) && string(m.appendToken(buf[:0], m.textIndex[i])) == token {
+		return m.textIndex[i], true
+	}
+	return 0, false
+}
+
+// UnkID returns the id of UNK token. The second value is false if the model has no such token.
+func (m Model) UnkID() (TokenID, bool) {
+	return TokenID(m.specialTokens.unk), m.specialTokens.unk != -1
+}
+
+// PadID returns the id of PAD token. The second value is false if the model has no such token.
+func (m Model) PadID() (TokenID, bool) {
+	return TokenID(m.specialTokens.pad), m.specialTokens.pad != -1
+}
+
+// BosID returns the id of BOS token. The second value is false if the model has no such token.
+func (m Model) BosID() (TokenID, bool) {
+	return TokenID(m.specialTokens.bos), m.specialTokens.bos != -1
+}
+
+// EosID returns the id of EOS token. The second value is false if the model has no such token.
+func (m Model) EosID() (TokenID, bool) {
+	return TokenID(m.specialTokens.eos), m.specialTokens.eos != -1
+}
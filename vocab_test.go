@@ -0,0 +1,80 @@
+package bpe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestModel_VocabSize(t *testing.T) {
+	req := require.New(t)
+	req.Equal(15, BPE.VocabSize())
+	model := BPE
+	model.specialTokens.bos = -1
+	req.Equal(14, model.VocabSize())
+}
+
+func TestModel_Vocab(t *testing.T) {
+	req := require.New(t)
+	req.Equal([]string{"<PAD>", "<UNK>", "<BOS>", "<EOS>", "_", "d", "c", "b", "a", "_a", "_c",
+		"_d", "_b", "ab", "aa"}, BPE.Vocab())
+	model := BPE
+	model.specialTokens.pad = -1
+	model.specialTokens.eos = -1
+	vocab := model.Vocab()
+	req.Len(vocab, 13)
+	req.Equal([]string{"<UNK>", "<BOS>", "_", "d"}, vocab[:4])
+}
+
+func TestModel_VocabIDs(t *testing.T) {
+	req := require.New(t)
+	ids := BPE.VocabIDs()
+	req.Len(ids, 15)
+	for i, id := range ids {
+		req.Equal(TokenID(i), id)
+	}
+	model := BPE
+	model.specialTokens.pad = -1
+	model.specialTokens.eos = -1
+	ids = model.VocabIDs()
+	vocab := model.Vocab()
+	req.Len(ids, len(vocab))
+	req.Equal([]TokenID{1, 2, 4, 5}, ids[:4])
+	for i, id := range ids {
+		token, err := model.IDToToken(id, false)
+		req.NoError(err)
+		req.Equal(vocab[i], token)
+	}
+}
+
+func TestModel_TokenToID(t *testing.T) {
+	req := require.New(t)
+	for id, token := range BPE.Vocab() {
+		tokenID, ok := BPE.TokenToID(token)
+		req.True(ok)
+		req.Equal(TokenID(id), tokenID)
+	}
+	_, ok := BPE.TokenToID("abc")
+	req.False(ok)
+	model := BPE
+	model.specialTokens.eos = -1
+	_, ok = model.TokenToID(eosToken)
+	req.False(ok)
+}
+
+func TestModel_SpecialIDs(t *testing.T) {
+	req := require.New(t)
+	model := BPE
+	model.specialTokens.bos = -1
+	id, ok := model.UnkID()
+	req.True(ok)
+	req.Equal(TokenID(1), id)
+	id, ok = model.PadID()
+	req.True(ok)
+	req.Equal(TokenID(0), id)
+	_, ok = model.BosID()
+	req.False(ok)
+	id, ok = model.EosID()
+	req.True(ok)
+	req.Equal(TokenID(3), id)
+}